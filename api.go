@@ -0,0 +1,538 @@
+package main
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+//go:embed api/openapi.json
+var openAPIDoc []byte
+
+const (
+	apiDefaultLimit = 20
+	apiMaxLimit     = 100
+)
+
+type apiServer struct {
+	st *state
+}
+
+type apiErrorBody struct {
+	Error apiErrorDetail `json:"error"`
+}
+
+type apiErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiPage struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+type apiList[T any] struct {
+	Data       []T     `json:"data"`
+	Pagination apiPage `json:"pagination"`
+}
+
+type apiUser struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	IsAdmin   bool      `json:"is_admin"`
+}
+
+type apiNewUser struct {
+	apiUser
+	ApiKey string `json:"api_key"`
+}
+
+type apiFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	UserID        uuid.UUID  `json:"user_id"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type apiFeedFollow struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	FeedName  string    `json:"feed_name"`
+	FeedUrl   string    `json:"feed_url,omitempty"`
+}
+
+type apiPost struct {
+	ID              uuid.UUID      `json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	Title           string         `json:"title"`
+	Url             string         `json:"url"`
+	Description     string         `json:"description"`
+	DescriptionHTML string         `json:"description_html"`
+	DescriptionText string         `json:"description_text"`
+	PublishedAt     time.Time      `json:"published_at"`
+	FeedID          uuid.UUID      `json:"feed_id"`
+	FeedTitle       string         `json:"feed_title"`
+	AlsoIn          string         `json:"also_in,omitempty"`
+	Author          string         `json:"author,omitempty"`
+	Categories      []string       `json:"categories"`
+	CommentsURL     string         `json:"comments_url,omitempty"`
+	Enclosures      []apiEnclosure `json:"enclosures"`
+}
+
+type apiEnclosure struct {
+	Url      string `json:"url"`
+	MimeType string `json:"mime_type,omitempty"`
+	Length   *int64 `json:"length,omitempty"`
+}
+
+func registerAPIRoutes(mux *http.ServeMux, s *state) {
+	api := &apiServer{st: s}
+	mux.HandleFunc("GET /v1/openapi.json", api.handleOpenAPI)
+	mux.HandleFunc("GET /v1/users", api.withUser(api.handleListUsers))
+	mux.HandleFunc("POST /v1/users", api.handleCreateUser)
+	mux.HandleFunc("GET /v1/feeds", api.withUser(api.handleListFeeds))
+	mux.HandleFunc("POST /v1/feeds", api.withUser(api.handleCreateFeed))
+	mux.HandleFunc("GET /v1/feed_follows", api.withUser(api.handleListFeedFollows))
+	mux.HandleFunc("POST /v1/feed_follows", api.withUser(api.handleCreateFeedFollow))
+	mux.HandleFunc("DELETE /v1/feed_follows/{feedID}", api.withUser(api.handleDeleteFeedFollow))
+	mux.HandleFunc("GET /v1/posts", api.withUser(api.handleListPosts))
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		respondError(w, http.StatusNotFound, "not_found", "no such endpoint")
+	})
+}
+
+// withUser resolves the calling user from the API key in the
+// "Authorization: Bearer <key>" header.
+func (api *apiServer) withUser(handler func(w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondError(w, http.StatusUnauthorized, "unauthorized", "api key required")
+			return
+		}
+		user, err := api.st.db.GetUserByAPIKeyHash(r.Context(), hashAPIKey(key))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusUnauthorized, "unauthorized", "invalid api key")
+			return
+		}
+		if err != nil {
+			respondInternalError(w, fmt.Errorf("failed to get user: %w", err))
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+func (api *apiServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDoc)
+}
+
+func (api *apiServer) handleListUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, offset, ok := parsePagination(w, r)
+	if !ok {
+		return
+	}
+	users, err := api.st.db.ListUsers(r.Context(), database.ListUsersParams{
+		Limit:  int32(limit + 1),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to list users: %w", err))
+		return
+	}
+	respondList(w, users, limit, offset, toAPIUser)
+}
+
+func (api *apiServer) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" {
+		respondError(w, http.StatusBadRequest, "invalid_request", "name is required")
+		return
+	}
+	_, err := api.st.db.GetUser(r.Context(), body.Name)
+	if err == nil {
+		respondError(w, http.StatusConflict, "conflict", "user already exists")
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondInternalError(w, fmt.Errorf("failed to check user: %w", err))
+		return
+	}
+
+	var passwordHash sql.NullString
+	if body.Password != "" {
+		passwordHash, err = hashPassword(body.Password)
+		if err != nil {
+			respondInternalError(w, fmt.Errorf("failed to hash password: %w", err))
+			return
+		}
+	}
+	count, err := api.st.db.CountUsers(r.Context())
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to count users: %w", err))
+		return
+	}
+
+	key, keyHash, err := generateAPIKey()
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to generate api key: %w", err))
+		return
+	}
+
+	now := time.Now()
+	user, err := api.st.db.CreateUser(r.Context(), database.CreateUserParams{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Name:         body.Name,
+		ApiKeyHash:   keyHash,
+		PasswordHash: passwordHash,
+		IsAdmin:      count == 0,
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("create user: %w", err))
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiNewUser{apiUser: toAPIUser(user), ApiKey: key})
+}
+
+func (api *apiServer) handleListFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	if feedURL := r.URL.Query().Get("url"); feedURL != "" {
+		feed, err := api.st.db.GetFeedByUrl(r.Context(), feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "not_found", "feed not found")
+			return
+		}
+		if err != nil {
+			respondInternalError(w, fmt.Errorf("failed to get feed: %w", err))
+			return
+		}
+		respondJSON(w, http.StatusOK, toAPIFeed(feed))
+		return
+	}
+
+	limit, offset, ok := parsePagination(w, r)
+	if !ok {
+		return
+	}
+	feeds, err := api.st.db.ListFeeds(r.Context(), database.ListFeedsParams{
+		Limit:  int32(limit + 1),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to list feeds: %w", err))
+		return
+	}
+	respondList(w, feeds, limit, offset, toAPIFeed)
+}
+
+func (api *apiServer) handleCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" || body.Url == "" {
+		respondError(w, http.StatusBadRequest, "invalid_request", "name and url are required")
+		return
+	}
+
+	feed, err := api.st.db.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      body.Name,
+		Url:       body.Url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "conflict", "feed with this url already exists")
+			return
+		}
+		respondInternalError(w, fmt.Errorf("can't create feed: %w", err))
+		return
+	}
+
+	_, err = api.st.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("could not follow feed: %w", err))
+		return
+	}
+	respondJSON(w, http.StatusCreated, toAPIFeed(feed))
+}
+
+func (api *apiServer) handleListFeedFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, offset, ok := parsePagination(w, r)
+	if !ok {
+		return
+	}
+	follows, err := api.st.db.ListFeedFollowsForUser(r.Context(), database.ListFeedFollowsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit + 1),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to get subscriptions: %w", err))
+		return
+	}
+	respondList(w, follows, limit, offset, func(f database.ListFeedFollowsForUserRow) apiFeedFollow {
+		return apiFeedFollow{
+			ID:        f.ID,
+			CreatedAt: f.CreatedAt,
+			UpdatedAt: f.UpdatedAt,
+			UserID:    f.UserID,
+			FeedID:    f.FeedID,
+			FeedName:  f.FeedName,
+			FeedUrl:   f.FeedUrl,
+		}
+	})
+}
+
+func (api *apiServer) handleCreateFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		FeedUrl string `json:"feed_url"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	feed, err := api.st.db.GetFeedByUrl(r.Context(), body.FeedUrl)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "not_found", "feed not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("failed to get feed: %w", err))
+		return
+	}
+
+	follow, err := api.st.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "conflict", "already following this feed")
+			return
+		}
+		respondInternalError(w, fmt.Errorf("could not follow feed: %w", err))
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiFeedFollow{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		UpdatedAt: follow.UpdatedAt,
+		UserID:    follow.UserID,
+		FeedID:    follow.FeedID,
+		FeedName:  follow.FeedName,
+		FeedUrl:   feed.Url,
+	})
+}
+
+func (api *apiServer) handleDeleteFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid_request", "invalid feed id")
+		return
+	}
+	err = api.st.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		respondInternalError(w, fmt.Errorf("can't unfollow: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) handleListPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, offset, ok := parsePagination(w, r)
+	if !ok {
+		return
+	}
+	posts, err := listPosts(r.Context(), api.st, user, database.GetPostsForUserParams{
+		Author:   nullString(r.URL.Query().Get("author")),
+		Category: nullString(r.URL.Query().Get("category")),
+		Search:   nullString(escapeLike(strings.TrimSpace(r.URL.Query().Get("q")))),
+	}, offset, limit+1)
+	if err != nil {
+		respondInternalError(w, err)
+		return
+	}
+	enclosures := make(map[uuid.UUID][]database.PostEnclosure)
+	for _, p := range posts[:min(len(posts), limit)] {
+		enclosures[p.ID], err = api.st.db.GetPostEnclosures(r.Context(), p.ID)
+		if err != nil {
+			respondInternalError(w, fmt.Errorf("failed to get enclosures: %w", err))
+			return
+		}
+	}
+	respondList(w, posts, limit, offset, func(p userPost) apiPost {
+		return toAPIPost(p, enclosures[p.ID])
+	})
+}
+
+func toAPIUser(u database.User) apiUser {
+	return apiUser{
+		ID:        u.ID,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		Name:      u.Name,
+		IsAdmin:   u.IsAdmin,
+	}
+}
+
+func toAPIFeed(f database.Feed) apiFeed {
+	feed := apiFeed{
+		ID:        f.ID,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+		Name:      f.Name,
+		Url:       f.Url,
+		UserID:    f.UserID,
+	}
+	if f.LastFetchedAt.Valid {
+		feed.LastFetchedAt = &f.LastFetchedAt.Time
+	}
+	return feed
+}
+
+func toAPIPost(p userPost, enclosures []database.PostEnclosure) apiPost {
+	post := apiPost{
+		ID:          p.ID,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Title:       p.Title,
+		Url:         p.Url,
+		Description: p.Description,
+		PublishedAt: p.PublishedAt,
+		FeedID:      p.FeedID,
+		FeedTitle:   p.FeedTitle,
+		AlsoIn:      p.AlsoIn,
+		Categories:  p.Categories,
+		Enclosures:  []apiEnclosure{},
+	}
+	post.DescriptionHTML, post.DescriptionText = postDescription(p.Description, p.DescriptionHtml, p.DescriptionText, p.Url)
+	if p.Author.Valid {
+		post.Author = p.Author.String
+	}
+	if p.CommentsUrl.Valid {
+		post.CommentsURL = p.CommentsUrl.String
+	}
+	for _, e := range enclosures {
+		enclosure := apiEnclosure{Url: e.Url, MimeType: e.MimeType}
+		if e.Length.Valid {
+			enclosure.Length = &e.Length.Int64
+		}
+		post.Enclosures = append(post.Enclosures, enclosure)
+	}
+	return post
+}
+
+// parsePagination reads limit and offset from the query string. On invalid
+// input it writes the error response itself and reports ok=false.
+func parsePagination(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	limit = apiDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > apiMaxLimit {
+			respondError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("limit must be between 1 and %d", apiMaxLimit))
+			return 0, 0, false
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "invalid_request", "offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = n
+	}
+	return limit, offset, true
+}
+
+// respondList expects rows fetched with limit+1 so it can tell whether
+// another page exists.
+func respondList[R, T any](w http.ResponseWriter, rows []R, limit, offset int, convert func(R) T) {
+	page := apiPage{Limit: limit, Offset: offset}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	data := make([]T, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, convert(row))
+	}
+	respondJSON(w, http.StatusOK, apiList[T]{Data: data, Pagination: page})
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func respondJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("api: failed to encode response: %v", err)
+	}
+}
+
+func respondError(w http.ResponseWriter, status int, code, message string) {
+	respondJSON(w, status, apiErrorBody{Error: apiErrorDetail{Code: code, Message: message}})
+}
+
+func respondInternalError(w http.ResponseWriter, err error) {
+	log.Printf("api: %v", err)
+	respondError(w, http.StatusInternalServerError, "internal", "internal server error")
+}
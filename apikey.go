@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+const (
+	apiKeyPrefix = "gator_"
+	apiKeyEnvVar = "GATOR_API_KEY"
+)
+
+// generateAPIKey returns a new random key together with the hash that is
+// stored in users.api_key_hash. The plain key is only ever shown once.
+func generateAPIKey() (key string, hash sql.NullString, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", sql.NullString{}, err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, hashAPIKey(key), nil
+}
+
+// hashAPIKey uses plain SHA-256: keys are 256 bits of randomness, so a slow
+// password hash would buy nothing and make every request slower.
+func hashAPIKey(key string) sql.NullString {
+	sum := sha256.Sum256([]byte(key))
+	return sql.NullString{String: hex.EncodeToString(sum[:]), Valid: true}
+}
+
+func handlerAPIKey(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 || cmd.args[0] != "rotate" {
+		return fmt.Errorf("usage: apikey rotate")
+	}
+
+	key, hash, err := generateAPIKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate api key: %w", err)
+	}
+	err = s.db.SetUserAPIKeyHash(context.Background(), database.SetUserAPIKeyHashParams{
+		ApiKeyHash: hash,
+		UpdatedAt:  time.Now(),
+		ID:         user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	fmt.Printf("new api key for %s: %s\n", user.Name, key)
+	fmt.Println("the previous key no longer works; store this one now, it will not be shown again")
+	return nil
+}
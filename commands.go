@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -16,13 +19,69 @@ import (
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db   *database.Queries
+	conn *sql.DB
+	cfg  *config.Config
 }
 
+// withTx runs fn against a Queries bound to a single transaction and
+// commits only if fn succeeds.
+func withTx(ctx context.Context, s *state, fn func(q *database.Queries) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(s.db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// command is one invocation. args holds the positional arguments only;
+// flags declared at register time are parsed out of the command line
+// wherever they appear and read back with the typed accessors below.
 type command struct {
-	name string
-	args []string
+	name  string
+	args  []string
+	flags *flag.FlagSet
+}
+
+// flagValue returns nil, which the accessors turn into the zero value,
+// for a flag the command never declared. That is a bug in the handler,
+// so it is logged rather than passed to the user as an error.
+func (cmd command) flagValue(name string) any {
+	var f *flag.Flag
+	if cmd.flags != nil {
+		f = cmd.flags.Lookup(name)
+	}
+	if f == nil {
+		log.Printf("bug: command %s has no --%s flag", cmd.name, name)
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+func (cmd command) boolFlag(name string) bool {
+	v, _ := cmd.flagValue(name).(bool)
+	return v
+}
+
+func (cmd command) stringFlag(name string) string {
+	v, _ := cmd.flagValue(name).(string)
+	return v
+}
+
+func (cmd command) intFlag(name string) int {
+	v, _ := cmd.flagValue(name).(int)
+	return v
+}
+
+func (cmd command) durationFlag(name string) time.Duration {
+	v, _ := cmd.flagValue(name).(time.Duration)
+	return v
 }
 
 func handlerLogin(s *state, cmd command) error {
@@ -30,7 +89,7 @@ func handlerLogin(s *state, cmd command) error {
 		return fmt.Errorf("username required")
 	}
 	name := cmd.args[0]
-	_, err := s.db.GetUser(context.Background(), name)
+	user, err := s.db.GetUser(context.Background(), name)
 	if errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("user does not exist")
 	}
@@ -38,7 +97,38 @@ func handlerLogin(s *state, cmd command) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	err = s.cfg.SetUser(cmd.args[0])
+	if user.PasswordHash.Valid {
+		password, err := readPassword("Password: ")
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		err = checkPassword(user.PasswordHash, password)
+		if err != nil {
+			return err
+		}
+	} else if user.IsAdmin && user.ApiKeyHash.Valid {
+		// Without a password nothing proves who is logging in.
+		return fmt.Errorf("%s is an admin without a password; set one with: %s=<api key> gator passwd", user.Name, apiKeyEnvVar)
+	} else if user.IsAdmin {
+		// Admins from before api keys and passwords have no other way in,
+		// so they get this one login on condition that they set a
+		// password right away.
+		fmt.Printf("%s is an admin without a password or api key; set a password now\n", user.Name)
+		hash, err := promptNewPassword()
+		if err != nil {
+			return err
+		}
+		err = s.db.SetUserPasswordHash(context.Background(), database.SetUserPasswordHashParams{
+			PasswordHash: hash,
+			UpdatedAt:    time.Now(),
+			ID:           user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set password: %w", err)
+		}
+	}
+
+	err = startSession(s, user)
 	if err != nil {
 		return fmt.Errorf("can't set the username: %w", err)
 	}
@@ -51,6 +141,7 @@ func handlerRegister(s *state, cmd command) error {
 		return fmt.Errorf("username required")
 	}
 	name := cmd.args[0]
+	withPassword := cmd.boolFlag("password")
 	now := time.Now()
 	_, err := s.db.GetUser(context.Background(), name)
 	if err == nil {
@@ -62,46 +153,162 @@ func handlerRegister(s *state, cmd command) error {
 		return fmt.Errorf("failed to check user: %w", err)
 	}
 
+	var passwordHash sql.NullString
+	if withPassword {
+		passwordHash, err = promptNewPassword()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The first account on a fresh database is the admin, otherwise nobody
+	// could ever run admin-only commands.
+	count, err := s.db.CountUsers(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+
+	key, keyHash, err := generateAPIKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate api key: %w", err)
+	}
+
 	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      name,
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Name:         name,
+		ApiKeyHash:   keyHash,
+		PasswordHash: passwordHash,
+		IsAdmin:      count == 0,
 	})
 	if err != nil {
 		return fmt.Errorf("create user: %w", err)
 	}
 
-	err = s.cfg.SetUser(name)
+	err = startSession(s, user)
 	if err != nil {
 		return fmt.Errorf("failed to set current user: %w", err)
 	}
 
 	fmt.Printf("user created: id=%s, name=%s\n", user.ID, user.Name)
+	if user.IsAdmin {
+		fmt.Println("this is the first account, so it has admin rights")
+	}
+	fmt.Printf("api key: %s\n", key)
+	fmt.Println("store the api key now, it will not be shown again (rotate with: gator apikey rotate)")
 	return nil
 }
 
-func handlerReset(s *state, cmd command, user database.User) error {
-	err := s.db.ResetUsers(context.Background())
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	if user.PasswordHash.Valid {
+		current, err := readPassword("Current password: ")
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		err = checkPassword(user.PasswordHash, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	hash, err := promptNewPassword()
 	if err != nil {
-		return fmt.Errorf("failed to reset database %w", err)
+		return err
+	}
+	err = s.db.SetUserPasswordHash(context.Background(), database.SetUserPasswordHashParams{
+		PasswordHash: hash,
+		UpdatedAt:    time.Now(),
+		ID:           user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	// A new password ends other sessions. Keep this one unless the command
+	// ran with an api key for someone other than the config's user.
+	if os.Getenv(apiKeyEnvVar) == "" || s.cfg.CurrentUserName == user.Name {
+		err = startSession(s, user)
+	} else {
+		err = endSession(s, user)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("password updated for %s\n", user.Name)
+	return nil
+}
+
+func promptNewPassword() (sql.NullString, error) {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to read password: %w", err)
+	}
+	if password == "" {
+		return sql.NullString{}, fmt.Errorf("password can't be empty")
+	}
+	confirm, err := readPassword("Repeat password: ")
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to read password: %w", err)
+	}
+	if password != confirm {
+		return sql.NullString{}, fmt.Errorf("passwords do not match")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hash, nil
+}
+
+func handlerAdmin(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 || (cmd.args[0] != "grant" && cmd.args[0] != "revoke") {
+		return fmt.Errorf("usage: admin <grant|revoke> <username>")
+	}
+	grant := cmd.args[0] == "grant"
+
+	target, err := s.db.GetUser(context.Background(), cmd.args[1])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user does not exist")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !grant && target.ID == user.ID {
+		return fmt.Errorf("you can't revoke your own admin rights")
+	}
+
+	err = s.db.SetUserAdmin(context.Background(), database.SetUserAdminParams{
+		IsAdmin:   grant,
+		UpdatedAt: time.Now(),
+		ID:        target.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update admin rights: %w", err)
+	}
+	if grant {
+		fmt.Printf("%s is now an admin\n", target.Name)
+	} else {
+		fmt.Printf("%s is no longer an admin\n", target.Name)
 	}
-	fmt.Println("reset complete")
 	return nil
 }
 
 func handlerUsers(s *state, cmd command, user database.User) error {
-	users, err := s.db.GetUsers(context.Background())
+	users, err := s.db.GetUsersWithStats(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to check users %w", err)
 
 	}
 	for _, user := range users {
 		name := user.Name
-		if name == s.cfg.CurrentUserName {
-			fmt.Printf("* %s (current)\n", name)
+		if user.IsAdmin {
+			name += " [admin]"
+		}
+		stats := fmt.Sprintf("follows: %d, read: %d", user.Follows, user.PostsRead)
+		if user.Name == s.cfg.CurrentUserName {
+			fmt.Printf("* %s (current) — %s\n", name, stats)
 		} else {
-			fmt.Printf("* %s\n", name)
+			fmt.Printf("* %s — %s\n", name, stats)
 		}
 	}
 	return nil
@@ -118,16 +325,24 @@ func handlerAgg(s *state, cmd command, user database.User) error {
 	}
 
 	fmt.Printf("Collecting feeds every %s\n", duration)
+	runAgg(context.Background(), s, duration)
+	return nil
+}
 
+func runAgg(ctx context.Context, s *state, duration time.Duration) {
 	ticker := time.NewTicker(duration)
 	defer ticker.Stop()
 
 	for {
-		err := handlerScrapeFeeds(s, cmd, user)
+		err := scrapeFeeds(s)
 		if err != nil {
 			fmt.Printf("Error scraping: %v\n", err)
 		}
-		<-ticker.C
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -139,14 +354,6 @@ func handlerAddfeed(s *state, cmd command, user database.User) error {
 	name := cmd.args[0]
 	url := cmd.args[1]
 
-	if s.cfg.CurrentUserName == "" {
-		return fmt.Errorf("no user logged in")
-	}
-	user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
-	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
-	}
-
 	params := database.CreateFeedParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now(),
@@ -192,15 +399,22 @@ func handlerFeeds(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+const followUsage = `usage:
+  follow <url> [--title <title>] [--notes <notes>]
+  follow rename <url> [title]
+  follow notes <url> [notes]`
+
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) < 1 {
-		return fmt.Errorf("usage: follow <url>")
+		return fmt.Errorf(followUsage)
 	}
-
-	user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
-	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+	switch cmd.args[0] {
+	case "rename":
+		return followRename(s, user, cmd.args[1:])
+	case "notes":
+		return followNotes(s, user, cmd.args[1:])
 	}
+
 	feed, err := s.db.GetFeedByUrl(context.Background(), cmd.args[0])
 	if err != nil {
 		return fmt.Errorf("feed not found: %w", err)
@@ -211,38 +425,78 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 		UpdatedAt: time.Now(),
 		UserID:    user.ID,
 		FeedID:    feed.ID,
+		Title:     nullString(cmd.stringFlag("title")),
+		Notes:     nullString(cmd.stringFlag("notes")),
 	}
 
 	follow, err := s.db.CreateFeedFollow(context.Background(), follows)
 	if err != nil {
 		return fmt.Errorf("could not follow feed: %w", err)
 	}
-	fmt.Printf("Following to %q as %q\n", follow.FeedName, follow.UserName)
+	fmt.Printf("Following to %q as %q\n", followTitle(follow.Title, follow.FeedName), follow.UserName)
 	return nil
 }
 
-func handlerFollowing(s *state, cmd command, user database.User) error {
-	user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUserName)
+// followRename sets the user's own title for a feed; without a title it
+// goes back to the feed's name.
+func followRename(s *state, user database.User, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: follow rename <url> [title]")
+	}
+	follow, feed, err := getFollowByURL(context.Background(), s, user, args[0])
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return err
 	}
-
-	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	title := strings.Join(args[1:], " ")
+	err = s.db.SetFeedFollowTitle(context.Background(), database.SetFeedFollowTitleParams{
+		Title:     nullString(title),
+		UpdatedAt: time.Now(),
+		ID:        follow.ID,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get subscriptions: %w", err)
+		return fmt.Errorf("can't rename follow: %w", err)
 	}
-
-	if len(follows) == 0 {
-		fmt.Println("You are not following any feeds.")
+	if title == "" {
+		fmt.Printf("%q is shown under its feed name again\n", feed.Name)
 		return nil
 	}
+	fmt.Printf("%q is now shown to you as %q\n", feed.Name, title)
+	return nil
+}
 
-	for _, f := range follows {
-		fmt.Printf("Name: %s\n", f.FeedName)
+func followNotes(s *state, user database.User, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: follow notes <url> [notes]")
+	}
+	follow, feed, err := getFollowByURL(context.Background(), s, user, args[0])
+	if err != nil {
+		return err
+	}
+	notes := strings.Join(args[1:], " ")
+	err = s.db.SetFeedFollowNotes(context.Background(), database.SetFeedFollowNotesParams{
+		Notes:     nullString(notes),
+		UpdatedAt: time.Now(),
+		ID:        follow.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't save notes: %w", err)
 	}
+	if notes == "" {
+		fmt.Printf("Notes for %q cleared\n", feed.Name)
+		return nil
+	}
+	fmt.Printf("Notes for %q saved\n", feed.Name)
 	return nil
 }
 
+// followTitle is what a follower sees as a feed's name.
+func followTitle(title sql.NullString, feedName string) string {
+	if title.Valid {
+		return title.String
+	}
+	return feedName
+}
+
 func handlerUnfollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) < 1 {
 		return fmt.Errorf("usage: unfollow <url>")
@@ -264,6 +518,15 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+// nullString maps an empty string to NULL, for optional text columns.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
+
 func parseTime(dateStr string) (time.Time, error) {
 	formats := []string{
 		time.RFC1123,
@@ -282,7 +545,17 @@ func parseTime(dateStr string) (time.Time, error) {
 }
 
 func handlerScrapeFeeds(s *state, cmd command, user database.User) error {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+	err := scrapeFeeds(s)
+	pendingDownloads.Wait()
+	return err
+}
+
+func scrapeFeeds(s *state) error {
+	feed, err := s.db.GetNextFeedToFetch(context.Background(), time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("No feeds are due for fetching")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("can't find feed to fetch %w", err)
 	}
@@ -294,10 +567,53 @@ func handlerScrapeFeeds(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("failed to mark feed as fetched: %w", err)
 	}
 
-	rssFeed, err := fetchFeed(context.Background(), feed.Url)
+	// Failing to build the request, e.g. because secret_key changed, counts
+	// as a failed fetch so that following shows it.
+	header, err := loadFeedHeaders(context.Background(), s, feed.ID)
+	if err == nil {
+		err = applyFeedCredentials(context.Background(), s, feed.ID, header)
+	}
+	var rssFeed *RSSFeed
+	if err == nil {
+		rssFeed, err = fetchFeed(context.Background(), feed.Url, header)
+	}
 	if err != nil {
+		markErr := s.db.MarkFeedFetchFailed(context.Background(), database.MarkFeedFetchFailedParams{
+			LastError: nullString(err.Error()),
+			ID:        feed.ID,
+		})
+		if markErr != nil {
+			log.Printf("failed to record fetch error: %v", markErr)
+		}
+		var retryErr *retryAfterError
+		if errors.As(err, &retryErr) {
+			// The site asked us to back off: skip the feed until then.
+			markErr = s.db.SetFeedNextFetchAt(context.Background(), database.SetFeedNextFetchAtParams{
+				NextFetchAt: sql.NullTime{Time: retryErr.until, Valid: true},
+				ID:          feed.ID,
+			})
+			if markErr != nil {
+				log.Printf("failed to postpone feed: %v", markErr)
+			}
+		}
 		return fmt.Errorf("failed to fetch RSS feed: %w", err)
 	}
+	err = s.db.MarkFeedFetchSucceeded(context.Background(), database.MarkFeedFetchSucceededParams{
+		LastSucceededAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:              feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark feed as fetched: %w", err)
+	}
+
+	filters, err := followerFilters(context.Background(), s, feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get filters: %w", err)
+	}
+	duplicates, err := loadDuplicateIndex(context.Background(), s)
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("Fetched %d posts from feed: %s\n", len(rssFeed.Channel.Item), feed.Name)
 	for _, item := range rssFeed.Channel.Item {
@@ -306,21 +622,65 @@ func handlerScrapeFeeds(s *state, cmd command, user database.User) error {
 			log.Printf("can't parse date %q: %v", item.PubDate, err)
 			continue
 		}
-		_, err = s.db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-			Title:       item.Title,
-			Url:         item.Link,
-			Description: item.Description,
-			PublishedAt: published,
-			FeedID:      feed.ID,
+		safeHTML, text := sanitizeHTML(item.Description, item.Link)
+		duration, episode, image := episodeFields(item)
+		var contentHTML, contentText string
+		if item.ContentEncoded != "" {
+			contentHTML, contentText = sanitizeHTML(item.ContentEncoded, item.Link)
+		}
+		post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+			ID:              uuid.New(),
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+			Title:           item.Title,
+			Url:             item.Link,
+			Description:     item.Description,
+			PublishedAt:     published,
+			FeedID:          feed.ID,
+			Simhash:         nullSimhash(simhash(item.Title, item.Description)),
+			DescriptionHtml: nullString(safeHTML),
+			DescriptionText: nullString(text),
+			ContentHtml:     nullString(contentHTML),
+			ContentText:     nullString(contentText),
+			Author:          nullString(item.ItemAuthor()),
+			Categories:      item.ItemCategories(),
+			CommentsUrl:     nullString(strings.TrimSpace(item.Comments)),
+			EpisodeDuration: duration,
+			EpisodeNumber:   episode,
+			EpisodeImage:    image,
 		})
 		if err != nil {
 			if strings.Contains(err.Error(), "duplicate key") {
 				continue
 			}
 			log.Printf("failed to insert post: %v", err)
+			continue
+		}
+		err = storeEnclosures(context.Background(), s, post, item.Enclosures)
+		if err != nil {
+			log.Printf("failed to store enclosures: %v", err)
+		}
+		err = clusterPost(context.Background(), s, duplicates, &post)
+		if err != nil {
+			log.Printf("failed to detect duplicates: %v", err)
+		}
+		if feed.FetchFullContent {
+			err = storeArticle(context.Background(), s, post)
+			if err != nil {
+				log.Printf("failed to fetch full content of %s: %v", post.Url, err)
+			}
+		}
+		err = applyIngestFilters(context.Background(), s, filters, post)
+		if err != nil {
+			log.Printf("failed to apply filters: %v", err)
+		}
+		// Auto-download is the feed owner's setting, so the owner's
+		// filters decide whether a post is worth downloading.
+		if feed.AutoDownload && !evaluateFilters(filters[feed.UserID], toFilterPost(post)).hide {
+			err = autoDownload(context.Background(), s, feed, post)
+			if err != nil {
+				log.Printf("failed to queue episode of %s: %v", post.Url, err)
+			}
 		}
 	}
 
@@ -337,39 +697,194 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 		limit = parsedLimit
 	}
 
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
-	})
+	posts, err := listPosts(context.Background(), s, user, database.GetPostsForUserParams{
+		Tag:      nullString(cmd.stringFlag("tag")),
+		Author:   nullString(cmd.stringFlag("author")),
+		Category: nullString(cmd.stringFlag("category")),
+	}, 0, limit)
 	if err != nil {
-		return fmt.Errorf("failed to get posts: %w", err)
+		return err
 	}
 
+	return printPosts(s, posts, cmd.boolFlag("full"))
+}
+
+// handlerSearch finds posts whose title, description or article text
+// contains the query, ignoring case. Filters and duplicates are handled
+// as in browse.
+func handlerSearch(s *state, cmd command, user database.User) error {
+	query := strings.TrimSpace(strings.Join(cmd.args, " "))
+	if query == "" {
+		return fmt.Errorf("usage: search <query> [--limit <n>] [--full]")
+	}
+	limit := cmd.intFlag("limit")
+	if limit < 1 {
+		return fmt.Errorf("usage: search <query> [--limit <n>] [--full]: limit must be at least 1")
+	}
+
+	posts, err := listPosts(context.Background(), s, user, database.GetPostsForUserParams{
+		Search: nullString(escapeLike(query)),
+	}, 0, limit)
+	if err != nil {
+		return err
+	}
+	return printPosts(s, posts, cmd.boolFlag("full"))
+}
+
+func printPosts(s *state, posts []userPost, full bool) error {
 	if len(posts) == 0 {
 		fmt.Println("no posts found.")
 		return nil
 	}
 
 	for _, post := range posts {
-		fmt.Printf("Title: %s\nUrl: %s\nPublished: %s\nFeed: %s\n\n", post.Title, post.Url, post.PublishedAt, post.FeedID)
+		fmt.Printf("Title: %s\nUrl: %s\nPublished: %s\nFeed: %s\n", post.Title, post.Url, post.PublishedAt, post.FeedTitle)
+		if post.AlsoIn != "" {
+			fmt.Printf("Also in: %s\n", post.AlsoIn)
+		}
+		if post.Author.Valid {
+			fmt.Printf("Author: %s\n", post.Author.String)
+		}
+		if len(post.Categories) > 0 {
+			fmt.Printf("Categories: %s\n", strings.Join(post.Categories, ", "))
+		}
+		if post.CommentsUrl.Valid {
+			fmt.Printf("Comments: %s\n", post.CommentsUrl.String)
+		}
+		enclosures, err := s.db.GetPostEnclosures(context.Background(), post.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get enclosures: %w", err)
+		}
+		for _, e := range enclosures {
+			fmt.Printf("Enclosure: %s\n", formatEnclosure(e))
+		}
+		if full {
+			_, content := postDescription(post.Description, post.DescriptionHtml, post.DescriptionText, post.Url)
+			if post.ContentText.Valid {
+				content = post.ContentText.String
+			}
+			fmt.Printf("\n%s\n", content)
+		}
+		fmt.Println()
 	}
 
 	return nil
 }
 
 type commands struct {
-	handlers map[string]func(*state, command) error
+	handlers map[string]registeredCommand
+}
+
+type registeredCommand struct {
+	name    string
+	handler func(*state, command) error
+	help    commandHelp
 }
 
-func (c *commands) register(name string, f func(*state, command) error) {
-	c.handlers[name] = f
+// commandHelp describes a command for "gator help". usage lists the
+// accepted forms without the command name; when it is empty a single form
+// is built from args.
+type commandHelp struct {
+	description string
+	usage       []string
+	args        []argSpec
+	flags       func(fs *flag.FlagSet)
+	hidden      bool
+}
+
+type argSpec struct {
+	name        string
+	description string
+	optional    bool
+}
+
+func (c *commands) register(name string, f func(*state, command) error, help commandHelp) {
+	c.handlers[name] = registeredCommand{name: name, handler: f, help: help}
 }
 
 func (c *commands) run(s *state, cmd command) error {
-	handler, ok := c.handlers[cmd.name]
+	rc, ok := c.handlers[cmd.name]
 	if !ok {
-		return fmt.Errorf("unknown command: %s", cmd.name)
+		if suggestion := c.suggest(cmd.name); suggestion != "" {
+			return fmt.Errorf("unknown command: %s (did you mean %q?)", cmd.name, suggestion)
+		}
+		return fmt.Errorf("unknown command: %s (see: gator help)", cmd.name)
 	}
 
-	return handler(s, cmd)
+	cmd.flags = rc.newFlagSet()
+	positional, err := parseInterspersed(cmd.flags, cmd.args)
+	if errors.Is(err, flag.ErrHelp) {
+		rc.printHelp()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v\n%s", cmd.name, err, rc.usageText())
+	}
+	cmd.args = positional
+
+	required := 0
+	for _, a := range rc.help.args {
+		if !a.optional {
+			required++
+		}
+	}
+	if len(cmd.args) < required {
+		return fmt.Errorf("%s", rc.usageText())
+	}
+
+	return rc.handler(s, cmd)
+}
+
+func (rc registeredCommand) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(rc.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if rc.help.flags != nil {
+		rc.help.flags(fs)
+	}
+	return fs
+}
+
+// parseInterspersed lets flags appear before, between or after positional
+// arguments ("feed delete <url> --yes"), which the flag package alone does
+// not allow. Everything after "--" is positional, and so are negative
+// numbers such as "-1".
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || isNegativeNumber(arg) {
+			positional = append(positional, arg)
+			args = args[1:]
+			continue
+		}
+		// Hand the flag package one flag at a time, together with its
+		// value when that is a separate argument.
+		n := 1
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := fs.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) && len(args) > 1 {
+			n = 2
+		}
+		err := fs.Parse(args[:n])
+		if err != nil {
+			return nil, err
+		}
+		args = args[n:]
+	}
+	return positional, nil
+}
+
+func isNegativeNumber(arg string) bool {
+	if len(arg) < 2 || (arg[1] < '0' || arg[1] > '9') && arg[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		yes        bool
+		limit      int
+		tag        string
+		wantErr    bool
+	}{
+		{name: "positional only", args: []string{"a", "b"}, positional: []string{"a", "b"}},
+		{name: "flag after positional", args: []string{"url", "--yes"}, positional: []string{"url"}, yes: true},
+		{name: "flag between positionals", args: []string{"a", "--tag", "go", "b"}, positional: []string{"a", "b"}, tag: "go"},
+		{name: "flag with equals", args: []string{"--limit=5", "a"}, positional: []string{"a"}, limit: 5},
+		{name: "negative positional", args: []string{"-1"}, positional: []string{"-1"}},
+		{name: "negative positional after flag", args: []string{"--yes", "-1", "-2.5"}, positional: []string{"-1", "-2.5"}, yes: true},
+		{name: "negative flag value", args: []string{"--limit", "-3", "a"}, positional: []string{"a"}, limit: -3},
+		{name: "bool flag does not take the next argument", args: []string{"--yes", "a"}, positional: []string{"a"}, yes: true},
+		{name: "double dash", args: []string{"a", "--", "--yes", "-x"}, positional: []string{"a", "--yes", "-x"}},
+		{name: "single dash is positional", args: []string{"-"}, positional: []string{"-"}},
+		{name: "unknown flag", args: []string{"a", "-x"}, wantErr: true},
+		{name: "not a number", args: []string{"-inf"}, wantErr: true},
+		{name: "missing value", args: []string{"a", "--tag"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			yes := fs.Bool("yes", false, "")
+			limit := fs.Int("limit", 0, "")
+			tag := fs.String("tag", "", "")
+
+			positional, err := parseInterspersed(fs, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseInterspersed(%q) = %q, want an error", tt.args, positional)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInterspersed(%q): %v", tt.args, err)
+			}
+			if !slices.Equal(positional, tt.positional) {
+				t.Errorf("positional = %q, want %q", positional, tt.positional)
+			}
+			if *yes != tt.yes || *limit != tt.limit || *tag != tt.tag {
+				t.Errorf("flags = yes:%v limit:%d tag:%q, want yes:%v limit:%d tag:%q", *yes, *limit, *tag, tt.yes, tt.limit, tt.tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+func (c *commands) handlerCompletion(s *state, cmd command) error {
+	names := c.visibleNames()
+	switch cmd.args[0] {
+	case "bash":
+		fmt.Print(bashCompletion(names))
+	case "zsh":
+		fmt.Print(zshCompletion(c, names))
+	case "fish":
+		fmt.Print(fishCompletion(c, names))
+	default:
+		return fmt.Errorf("usage: completion bash|zsh|fish")
+	}
+	return nil
+}
+
+// handlerComplete backs the dynamic part of the completion scripts. It is
+// hidden from help and prints one candidate per line. User names are only
+// listed to someone who is logged in.
+func handlerComplete(s *state, cmd command) error {
+	switch cmd.args[0] {
+	case "feeds":
+		feeds, err := s.db.GetAllFeeds(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, f := range feeds {
+			fmt.Println(f.Url)
+		}
+	case "users":
+		return middlewareLoggedIn(completeUsers)(s, cmd)
+	default:
+		return fmt.Errorf("unknown completion kind: %s", cmd.args[0])
+	}
+	return nil
+}
+
+func completeUsers(s *state, cmd command, user database.User) error {
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		fmt.Println(u.Name)
+	}
+	return nil
+}
+
+func (c *commands) visibleNames() []string {
+	var names []string
+	for name, rc := range c.handlers {
+		if !rc.help.hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bashCompletion(names []string) string {
+	return fmt.Sprintf(`# bash completion for gator; load with: source <(gator completion bash)
+_gator() {
+    local cur
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n : cur
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+    fi
+
+    local i=1
+    local profile=()
+    if [ "${COMP_WORDS[1]}" = "--profile" ]; then
+        i=3
+        profile=(--profile "${COMP_WORDS[2]}")
+    fi
+    if [ "$COMP_CWORD" -eq "$i" ]; then
+        COMPREPLY=( $(compgen -W "%s" -- "$cur") )
+        return
+    fi
+
+    case "${COMP_WORDS[$i]}" in
+        follow|unfollow)
+            COMPREPLY=( $(compgen -W "$(gator "${profile[@]}" __complete feeds 2>/dev/null)" -- "$cur") )
+            ;;
+        login)
+            COMPREPLY=( $(compgen -W "$(gator "${profile[@]}" __complete users 2>/dev/null)" -- "$cur") )
+            ;;
+        help)
+            COMPREPLY=( $(compgen -W "%s" -- "$cur") )
+            ;;
+        completion)
+            COMPREPLY=( $(compgen -W "bash zsh fish" -- "$cur") )
+            ;;
+    esac
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -F _gator gator
+`, strings.Join(names, " "), strings.Join(names, " "))
+}
+
+func zshCompletion(c *commands, names []string) string {
+	var described []string
+	for _, name := range names {
+		desc := strings.ReplaceAll(c.handlers[name].help.description, ":", `\:`)
+		desc = strings.ReplaceAll(desc, "'", `'\''`)
+		described = append(described, fmt.Sprintf("        '%s:%s'", name, desc))
+	}
+	return fmt.Sprintf(`#compdef gator
+# zsh completion for gator; load with: source <(gator completion zsh)
+_gator() {
+    local -a cmds
+    cmds=(
+%s
+    )
+    local i=2
+    local -a profile
+    if [[ $words[2] == --profile ]]; then
+        i=4
+        profile=(--profile $words[3])
+    fi
+    if (( CURRENT == i )); then
+        _describe 'command' cmds
+        return
+    fi
+    case $words[i] in
+        follow|unfollow)
+            compadd -- ${(f)"$(gator $profile __complete feeds 2>/dev/null)"}
+            ;;
+        login)
+            compadd -- ${(f)"$(gator $profile __complete users 2>/dev/null)"}
+            ;;
+        help)
+            _describe 'command' cmds
+            ;;
+        completion)
+            compadd bash zsh fish
+            ;;
+    esac
+}
+compdef _gator gator
+`, strings.Join(described, "\n"))
+}
+
+func fishCompletion(c *commands, names []string) string {
+	var b strings.Builder
+	b.WriteString("# fish completion for gator; load with: gator completion fish | source\n")
+	b.WriteString("complete -c gator -f\n")
+	// A leading --profile <name> is skipped when looking for the command
+	// and passed on to __complete.
+	b.WriteString(`function __gator_needs_command
+    set -l words (commandline -opc)
+    set -l i 2
+    if test "$words[2]" = --profile
+        set i 4
+    end
+    test (count $words) -lt $i
+end
+function __gator_profile
+    set -l words (commandline -opc)
+    if test (count $words) -ge 3; and test "$words[2]" = --profile
+        echo --profile
+        echo $words[3]
+    end
+end
+`)
+	for _, name := range names {
+		desc := strings.ReplaceAll(c.handlers[name].help.description, "'", `\'`)
+		fmt.Fprintf(&b, "complete -c gator -n __gator_needs_command -a %s -d '%s'\n", name, desc)
+	}
+	b.WriteString("complete -c gator -n '__fish_seen_subcommand_from follow unfollow' -a '(gator (__gator_profile) __complete feeds 2>/dev/null)'\n")
+	b.WriteString("complete -c gator -n '__fish_seen_subcommand_from login' -a '(gator (__gator_profile) __complete users 2>/dev/null)'\n")
+	fmt.Fprintf(&b, "complete -c gator -n '__fish_seen_subcommand_from help' -a '%s'\n", strings.Join(names, " "))
+	b.WriteString("complete -c gator -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n")
+	return b.String()
+}
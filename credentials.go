@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/net/http/httpguts"
+)
+
+const feedAuthUsage = `usage:
+  feed auth set <url> basic <username>
+  feed auth set <url> bearer|cookie
+  feed auth show <url>
+  feed auth remove <url>`
+
+// Headers that carry credentials. They can only be set with feed auth,
+// which stores them encrypted, and are never printed.
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+func feedAuth(s *state, feed database.Feed, action string, args []string) error {
+	switch action {
+	case "set":
+		return feedAuthSet(s, feed, args)
+	case "show":
+		return feedAuthShow(s, feed)
+	case "remove":
+		return feedAuthRemove(s, feed)
+	default:
+		return fmt.Errorf(feedAuthUsage)
+	}
+}
+
+// feedAuthSet prompts for the secret instead of taking it as an argument
+// so that it doesn't end up in the shell history.
+func feedAuthSet(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf(feedAuthUsage)
+	}
+	kind := args[0]
+	var secret string
+	var err error
+	switch kind {
+	case "basic":
+		if len(args) < 2 || strings.Contains(args[1], ":") {
+			return fmt.Errorf("usage: feed auth set <url> basic <username>")
+		}
+		var password string
+		password, err = readPassword("Password: ")
+		secret = args[1] + ":" + password
+	case "bearer":
+		secret, err = readPassword("Token: ")
+	case "cookie":
+		secret, err = readPassword("Cookie: ")
+	default:
+		return fmt.Errorf(feedAuthUsage)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read secret: %w", err)
+	}
+	secret = strings.TrimSpace(secret)
+	if secret == "" || !httpguts.ValidHeaderFieldValue(secret) {
+		return fmt.Errorf("invalid %s credentials", kind)
+	}
+
+	key, err := secretKey(s, true)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealSecret(key, feed.ID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+	err = s.db.UpsertFeedCredential(context.Background(), database.UpsertFeedCredentialParams{
+		FeedID:    feed.ID,
+		Kind:      kind,
+		Secret:    sealed,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("can't save credentials: %w", err)
+	}
+	fmt.Printf("Saved %s credentials for %q\n", kind, feed.Name)
+	return nil
+}
+
+func feedAuthShow(s *state, feed database.Feed) error {
+	cred, err := s.db.GetFeedCredential(context.Background(), feed.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("No credentials for %q\n", feed.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %w", err)
+	}
+	fmt.Printf("%q uses %s credentials (set %s)\n", feed.Name, cred.Kind, cred.UpdatedAt.Format("2006-01-02 15:04"))
+	return nil
+}
+
+func feedAuthRemove(s *state, feed database.Feed) error {
+	n, err := s.db.DeleteFeedCredential(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("can't remove credentials: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%q has no credentials", feed.Name)
+	}
+	fmt.Printf("Removed the credentials for %q\n", feed.Name)
+	return nil
+}
+
+// applyFeedCredentials adds the feed's stored credentials, if it has any,
+// to the request headers. The HTTP client drops them when a feed
+// redirects to another host.
+func applyFeedCredentials(ctx context.Context, s *state, feedID uuid.UUID, header http.Header) error {
+	cred, err := s.db.GetFeedCredential(ctx, feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feed credentials: %w", err)
+	}
+	key, err := secretKey(s, false)
+	if err != nil {
+		return err
+	}
+	secret, err := openSecret(key, feedID, cred.Secret)
+	if err != nil {
+		return err
+	}
+	switch cred.Kind {
+	case "basic":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(secret)))
+	case "bearer":
+		header.Set("Authorization", "Bearer "+secret)
+	case "cookie":
+		header.Set("Cookie", secret)
+	}
+	return nil
+}
+
+// secretKey returns the profile's key for feed credentials. With create
+// set, a missing key is generated and saved to the config.
+func secretKey(s *state, create bool) ([]byte, error) {
+	if s.cfg.SecretKey == "" {
+		if !create {
+			return nil, fmt.Errorf("feed has credentials but the profile has no secret_key in the config")
+		}
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate secret key: %w", err)
+		}
+		err = s.cfg.SetSecretKey(base64.StdEncoding.EncodeToString(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save secret key: %w", err)
+		}
+		return key, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(s.cfg.SecretKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid secret_key in the config: want 32 bytes in base64")
+	}
+	return key, nil
+}
+
+// sealSecret encrypts with AES-256-GCM and prepends the nonce. The feed id
+// is authenticated too, so a secret copied to another feed won't decrypt.
+func sealSecret(key []byte, feedID uuid.UUID, secret string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, []byte(secret), feedID[:]), nil
+}
+
+func openSecret(key []byte, feedID uuid.UUID, sealed []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("stored credentials are corrupt")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, feedID[:])
+	if err != nil {
+		return "", fmt.Errorf("can't decrypt feed credentials: was secret_key changed?")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSealSecret(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	otherKey := bytes.Repeat([]byte{2}, 32)
+	feedID := uuid.New()
+	const secret = "Authorization: Bearer s3cret"
+
+	sealed, err := sealSecret(key, feedID, secret)
+	if err != nil {
+		t.Fatalf("sealSecret(): %v", err)
+	}
+	if bytes.Contains(sealed, []byte("s3cret")) {
+		t.Fatalf("sealed secret contains the plaintext")
+	}
+	again, err := sealSecret(key, feedID, secret)
+	if err != nil {
+		t.Fatalf("sealSecret(): %v", err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Errorf("sealing twice gave the same bytes; the nonce isn't random")
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	tamperedNonce := bytes.Clone(sealed)
+	tamperedNonce[0] ^= 1
+
+	tests := []struct {
+		name    string
+		key     []byte
+		feedID  uuid.UUID
+		sealed  []byte
+		wantErr bool
+	}{
+		{name: "round trip", key: key, feedID: feedID, sealed: sealed},
+		{name: "wrong key", key: otherKey, feedID: feedID, sealed: sealed, wantErr: true},
+		{name: "other feed", key: key, feedID: uuid.New(), sealed: sealed, wantErr: true},
+		{name: "tampered ciphertext", key: key, feedID: feedID, sealed: tampered, wantErr: true},
+		{name: "tampered nonce", key: key, feedID: feedID, sealed: tamperedNonce, wantErr: true},
+		{name: "truncated", key: key, feedID: feedID, sealed: sealed[:5], wantErr: true},
+		{name: "empty", key: key, feedID: feedID, sealed: nil, wantErr: true},
+		{name: "invalid key", key: []byte("short"), feedID: feedID, sealed: sealed, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openSecret(tt.key, tt.feedID, tt.sealed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("openSecret() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openSecret(): %v", err)
+			}
+			if got != secret {
+				t.Errorf("openSecret() = %q, want %q", got, secret)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// storeEnclosures saves the media files attached to a feed item. Entries
+// without a URL are skipped; a missing or malformed length is stored as
+// unknown.
+func storeEnclosures(ctx context.Context, s *state, post database.Post, enclosures []RSSEnclosure) error {
+	for _, e := range enclosures {
+		enclosureURL, ok := safeURL(e.URL, nil, []string{"http", "https"})
+		if !ok {
+			continue
+		}
+		var length int64
+		if n, err := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64); err == nil && n > 0 {
+			length = n
+		}
+		err := s.db.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			PostID:    post.ID,
+			Url:       enclosureURL,
+			MimeType:  strings.TrimSpace(e.Type),
+			Length:    nullInt64(length),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatEnclosure describes an enclosure on one line, e.g.
+// "https://example.com/ep1.mp3 (audio/mpeg, 24.1 MB)".
+func formatEnclosure(e database.PostEnclosure) string {
+	var details []string
+	if e.MimeType != "" {
+		details = append(details, e.MimeType)
+	}
+	if e.Length.Valid {
+		details = append(details, formatBytes(e.Length.Int64))
+	}
+	if len(details) == 0 {
+		return e.Url
+	}
+	return fmt.Sprintf("%s (%s)", e.Url, strings.Join(details, ", "))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
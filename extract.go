@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// article is the main content of a web page as found by extractArticle.
+type article struct {
+	HTML string
+	Text string
+}
+
+var (
+	positiveClassRe = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeClassRe = regexp.MustCompile(`(?i)ad-|banner|combx|comment|community|disqus|footer|header|menu|meta|nav|popup|promo|related|share|sidebar|social|sponsor|subscribe|widget`)
+)
+
+// Elements that never hold article text.
+var strippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Footer:   true,
+	atom.Header:   true,
+	atom.Svg:      true,
+}
+
+// fetchArticle downloads a post's link and extracts its main content.
+func fetchArticle(ctx context.Context, url string) (article, error) {
+	body, err := fetchURL(ctx, url, acceptPage, nil)
+	if err != nil {
+		return article{}, err
+	}
+	return extractArticle(body)
+}
+
+// storeArticle saves the extracted article of a post for browse --full.
+func storeArticle(ctx context.Context, s *state, post database.Post) error {
+	a, err := fetchArticle(ctx, post.Url)
+	if err != nil {
+		return err
+	}
+	safeHTML, text := sanitizeHTML(a.HTML, post.Url)
+	return s.db.SetPostContent(ctx, database.SetPostContentParams{
+		ContentHtml: nullString(safeHTML),
+		ContentText: nullString(text),
+		UpdatedAt:   time.Now(),
+		ID:          post.ID,
+	})
+}
+
+// extractArticle finds the element that most likely holds the article with
+// a simplified version of the Readability heuristics: paragraphs score
+// their parent and grandparent by length and commas, class and id names
+// push containers up or down, and link-heavy containers are penalized.
+func extractArticle(page []byte) (article, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return article{}, fmt.Errorf("failed to parse page: %w", err)
+	}
+	removeNodes(doc, func(n *html.Node) bool {
+		return n.Type == html.CommentNode || (n.Type == html.ElementNode && strippedElements[n.DataAtom])
+	})
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n)
+			if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+				scores[n] += 25
+			}
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td) {
+			return
+		}
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return article{}, fmt.Errorf("no article content found")
+	}
+
+	removeNodes(best, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n != best && classWeight(n) < 0 && linkDensity(n) > 0.3
+	})
+
+	var buf bytes.Buffer
+	for c := best.FirstChild; c != nil; c = c.NextSibling {
+		err := html.Render(&buf, c)
+		if err != nil {
+			return article{}, fmt.Errorf("failed to render article: %w", err)
+		}
+	}
+	return article{
+		HTML: strings.TrimSpace(buf.String()),
+		Text: blockText(best),
+	}, nil
+}
+
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, a := range n.Attr {
+		if a.Key != "class" && a.Key != "id" {
+			continue
+		}
+		if negativeClassRe.MatchString(a.Val) {
+			weight -= 25
+		}
+		if positiveClassRe.MatchString(a.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of an element's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+	var links int
+	walk(n, func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			links += len(textContent(c))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func removeNodes(n *html.Node, remove func(*html.Node) bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if remove(c) {
+			n.RemoveChild(c)
+		} else {
+			removeNodes(c, remove)
+		}
+		c = next
+	}
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	})
+	return sb.String()
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Section: true, atom.Article: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+}
+
+var (
+	whitespaceRe = regexp.MustCompile(`\s+`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// blockText renders an element as plain text with a blank line between
+// blocks and whitespace inside them collapsed.
+func blockText(n *html.Node) string {
+	var sb strings.Builder
+	var render func(*html.Node)
+	render = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(whitespaceRe.ReplaceAllString(n.Data, " "))
+			return
+		case n.Type == html.ElementNode && blockElements[n.DataAtom]:
+			sb.WriteString("\n\n")
+			defer sb.WriteString("\n\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			render(c)
+		}
+	}
+	render(n)
+
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"golang.org/x/net/http/httpguts"
+)
+
+const feedUsage = `usage:
+  feed rename <url> <name>
+  feed set-url <url> <new-url>
+  feed delete <url> [--yes]
+  feed transfer <url> <username>
+  feed full-content <url> on|off
+  feed auto-download <url> on|off
+  feed headers <url>
+  feed set-header <url> <name> [value]
+  feed auth set|show|remove <url>`
+
+func handlerFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf(feedUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+	var action string
+	if sub == "auth" {
+		// feed auth <action> <url> ...
+		if len(args) < 2 {
+			return fmt.Errorf(feedAuthUsage)
+		}
+		action, args = args[0], args[1:]
+	}
+
+	feed, err := s.db.GetFeedByUrl(context.Background(), args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("feed not found")
+	}
+	if err != nil {
+		return fmt.Errorf("can't find the feed %w", err)
+	}
+	if feed.UserID != user.ID && !user.IsAdmin {
+		return fmt.Errorf("only the user who added the feed or an admin can change it")
+	}
+
+	switch sub {
+	case "rename":
+		return feedRename(s, feed, args[1:])
+	case "set-url":
+		return feedSetURL(s, feed, args[1:])
+	case "delete":
+		return feedDelete(s, feed, cmd.boolFlag("yes"))
+	case "transfer":
+		return feedTransfer(s, feed, args[1:])
+	case "full-content":
+		return feedFullContent(s, feed, args[1:])
+	case "auto-download":
+		return feedAutoDownload(s, feed, args[1:])
+	case "headers":
+		return feedHeaders(s, feed)
+	case "set-header":
+		return feedSetHeader(s, feed, args[1:])
+	case "auth":
+		return feedAuth(s, feed, action, args[1:])
+	default:
+		return fmt.Errorf(feedUsage)
+	}
+}
+
+func feedRename(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: feed rename <url> <name>")
+	}
+	err := s.db.RenameFeed(context.Background(), database.RenameFeedParams{
+		Name:      args[0],
+		UpdatedAt: time.Now(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't rename feed: %w", err)
+	}
+	fmt.Printf("Feed %q renamed to %q\n", feed.Name, args[0])
+	return nil
+}
+
+func feedSetURL(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: feed set-url <url> <new-url>")
+	}
+	err := s.db.SetFeedUrl(context.Background(), database.SetFeedUrlParams{
+		Url:       args[0],
+		UpdatedAt: time.Now(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("another feed already uses %s", args[0])
+		}
+		return fmt.Errorf("can't change feed url: %w", err)
+	}
+	fmt.Printf("Feed %q now points to %s\n", feed.Name, args[0])
+	return nil
+}
+
+func feedDelete(s *state, feed database.Feed, yes bool) error {
+	counts, err := s.db.GetFeedDeletionCounts(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count followers: %w", err)
+	}
+	fmt.Printf("Feed %q has %d followers and %d posts; all of them will be removed.\n", feed.Name, counts.Followers, counts.Posts)
+
+	if !yes {
+		ok, err := confirm(`Type "yes" to continue: `)
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !ok {
+			fmt.Println("delete cancelled")
+			return nil
+		}
+	}
+
+	err = s.db.DeleteFeed(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("can't delete feed: %w", err)
+	}
+	fmt.Printf("Feed %q deleted\n", feed.Name)
+	return nil
+}
+
+func feedTransfer(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: feed transfer <url> <username>")
+	}
+	target, err := s.db.GetUser(context.Background(), args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user does not exist")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = s.db.SetFeedOwner(context.Background(), database.SetFeedOwnerParams{
+		UserID:    target.ID,
+		UpdatedAt: time.Now(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't transfer feed: %w", err)
+	}
+	fmt.Printf("Feed %q now belongs to %q\n", feed.Name, target.Name)
+	return nil
+}
+
+// feedFullContent turns on downloading each new post's page and storing
+// the extracted article next to the feed's own description.
+func feedFullContent(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 || (args[0] != "on" && args[0] != "off") {
+		return fmt.Errorf("usage: feed full-content <url> on|off")
+	}
+	on := args[0] == "on"
+	err := s.db.SetFeedFetchFullContent(context.Background(), database.SetFeedFetchFullContentParams{
+		FetchFullContent: on,
+		UpdatedAt:        time.Now(),
+		ID:               feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't change feed: %w", err)
+	}
+	if on {
+		fmt.Printf("Full articles will be fetched for new posts of %q\n", feed.Name)
+		return nil
+	}
+	fmt.Printf("Full articles will no longer be fetched for %q\n", feed.Name)
+	return nil
+}
+
+func feedHeaders(s *state, feed database.Feed) error {
+	headers, err := s.db.GetFeedHeaders(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feed headers: %w", err)
+	}
+	if len(headers) == 0 {
+		fmt.Printf("No custom headers for %q\n", feed.Name)
+		return nil
+	}
+	for _, h := range headers {
+		value := h.Value
+		if credentialHeaders[h.Name] {
+			value = "[redacted]"
+		}
+		fmt.Printf("%s: %s\n", h.Name, value)
+	}
+	return nil
+}
+
+// Headers that gator manages itself and a feed can't override.
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Range":             true,
+}
+
+// feedSetHeader adds a header to every request for the feed, or removes
+// it when no value is given.
+func feedSetHeader(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: feed set-header <url> <name> [value]")
+	}
+	name := http.CanonicalHeaderKey(args[0])
+	if !httpguts.ValidHeaderFieldName(name) {
+		return fmt.Errorf("invalid header name %q", args[0])
+	}
+	if reservedHeaders[name] {
+		return fmt.Errorf("the %s header can't be set per feed", name)
+	}
+	if credentialHeaders[name] && len(args) >= 2 {
+		return fmt.Errorf("the %s header holds credentials; use feed auth set so that it is stored encrypted", name)
+	}
+
+	if len(args) < 2 {
+		n, err := s.db.DeleteFeedHeader(context.Background(), database.DeleteFeedHeaderParams{
+			FeedID: feed.ID,
+			Name:   name,
+		})
+		if err != nil {
+			return fmt.Errorf("can't remove header: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%q has no %s header", feed.Name, name)
+		}
+		fmt.Printf("Removed the %s header from %q\n", name, feed.Name)
+		return nil
+	}
+
+	value := strings.Join(args[1:], " ")
+	if !httpguts.ValidHeaderFieldValue(value) {
+		return fmt.Errorf("invalid value for %s", name)
+	}
+	err := s.db.UpsertFeedHeader(context.Background(), database.UpsertFeedHeaderParams{
+		FeedID:    feed.ID,
+		Name:      name,
+		Value:     value,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("can't set header: %w", err)
+	}
+	fmt.Printf("Requests for %q will send %s\n", feed.Name, name)
+	return nil
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"html"
-	"io"
 	"net/http"
+	"strings"
 )
 
 type RSSFeed struct {
@@ -19,34 +19,63 @@ type RSSFeed struct {
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title          string         `xml:"title"`
+	Link           string         `xml:"link"`
+	Description    string         `xml:"description"`
+	PubDate        string         `xml:"pubDate"`
+	Author         string         `xml:"author"`
+	Creator        string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories     []string       `xml:"category"`
+	ContentEncoded string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Comments       string         `xml:"comments"`
+	Enclosures     []RSSEnclosure `xml:"enclosure"`
+
+	// iTunes podcast fields.
+	Duration string   `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode  string   `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	Image    RSSImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	var feed RSSFeed
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch %w", err)
-	}
+type RSSImage struct {
+	Href string `xml:"href,attr"`
+}
 
-	req.Header.Set("User-Agent", "gator")
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
 
-	client := http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+// ItemAuthor prefers dc:creator, which holds a name, over the RSS author
+// element, which is supposed to be an email address.
+func (item RSSItem) ItemAuthor() string {
+	if author := strings.TrimSpace(item.Creator); author != "" {
+		return author
 	}
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	return strings.TrimSpace(item.Author)
+}
+
+// ItemCategories returns the item's categories trimmed and without
+// duplicates.
+func (item RSSItem) ItemCategories() []string {
+	categories := []string{}
+	seen := make(map[string]bool)
+	for _, c := range item.Categories {
+		c = strings.TrimSpace(c)
+		if c == "" || seen[strings.ToLower(c)] {
+			continue
+		}
+		seen[strings.ToLower(c)] = true
+		categories = append(categories, c)
 	}
-	defer res.Body.Close()
+	return categories
+}
 
-	if res.StatusCode > 299 {
-		return nil, fmt.Errorf("response failed with status code: %d ", res.StatusCode)
+func fetchFeed(ctx context.Context, feedURL string, header http.Header) (*RSSFeed, error) {
+	var feed RSSFeed
+	body, err := fetchURL(ctx, feedURL, acceptFeed, header)
+	if err != nil {
+		return nil, err
 	}
 	err = xml.Unmarshal(body, &feed)
 	if err != nil {
@@ -56,6 +85,11 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
 		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+		feed.Channel.Item[i].Author = html.UnescapeString(feed.Channel.Item[i].Author)
+		feed.Channel.Item[i].Creator = html.UnescapeString(feed.Channel.Item[i].Creator)
+		for j, c := range feed.Channel.Item[i].Categories {
+			feed.Channel.Item[i].Categories[j] = html.UnescapeString(c)
+		}
 	}
 
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
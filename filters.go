@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const filterUsage = `usage:
+  filter add <include|exclude|read|star> <pattern> [--field any|title|description|url|author|category] [--regex] [--feed <url>]
+  filter list
+  filter remove <id>`
+
+func handlerFilter(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf(filterUsage)
+	}
+	ctx := context.Background()
+	switch cmd.args[0] {
+	case "add":
+		if len(cmd.args) < 3 {
+			return fmt.Errorf("usage: filter add <include|exclude|read|star> <pattern>")
+		}
+		return filterAdd(ctx, s, cmd, user, cmd.args[1], strings.Join(cmd.args[2:], " "))
+	case "list":
+		filters, err := s.db.ListFiltersForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get filters: %w", err)
+		}
+		if len(filters) == 0 {
+			fmt.Println("You have no filters.")
+			return nil
+		}
+		for _, f := range filters {
+			scope := "all feeds"
+			if f.FeedUrl.Valid {
+				scope = f.FeedUrl.String
+			}
+			fmt.Printf("%s  %-7s  %-11s  %s %q  (%s)\n", shortID(f.ID), f.Action, f.Field, f.MatchType, f.Pattern, scope)
+		}
+		return nil
+	case "remove":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("usage: filter remove <id>")
+		}
+		return filterRemove(ctx, s, user, cmd.args[1])
+	default:
+		return fmt.Errorf(filterUsage)
+	}
+}
+
+func filterAdd(ctx context.Context, s *state, cmd command, user database.User, action, pattern string) error {
+	switch action {
+	case "include", "exclude", "read", "star":
+	default:
+		return fmt.Errorf("invalid action %q: want include, exclude, read or star", action)
+	}
+	field := cmd.stringFlag("field")
+	switch field {
+	case "any", "title", "description", "url", "author", "category":
+	default:
+		return fmt.Errorf("invalid field %q: want any, title, description, url, author or category", field)
+	}
+	matchType := "keyword"
+	if cmd.boolFlag("regex") {
+		matchType = "regex"
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	var feedID uuid.NullUUID
+	if url := cmd.stringFlag("feed"); url != "" {
+		_, feed, err := getFollowByURL(ctx, s, user, url)
+		if err != nil {
+			return err
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	filter, err := s.db.CreateFilter(ctx, database.CreateFilterParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Field:     field,
+		MatchType: matchType,
+		Pattern:   pattern,
+		Action:    action,
+	})
+	if err != nil {
+		return fmt.Errorf("can't create filter: %w", err)
+	}
+	fmt.Printf("Filter %s added\n", shortID(filter.ID))
+	return nil
+}
+
+// filterRemove accepts any unambiguous prefix of the id shown by filter list.
+func filterRemove(ctx context.Context, s *state, user database.User, prefix string) error {
+	filters, err := s.db.ListFiltersForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get filters: %w", err)
+	}
+	var matched []uuid.UUID
+	for _, f := range filters {
+		if strings.HasPrefix(f.ID.String(), strings.ToLower(prefix)) {
+			matched = append(matched, f.ID)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return fmt.Errorf("filter %s not found", prefix)
+	case 1:
+	default:
+		return fmt.Errorf("filter id %s is ambiguous", prefix)
+	}
+
+	_, err = s.db.DeleteFilter(ctx, database.DeleteFilterParams{ID: matched[0], UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("can't remove filter: %w", err)
+	}
+	fmt.Printf("Filter %s removed\n", shortID(matched[0]))
+	return nil
+}
+
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
+
+type filterRule struct {
+	database.Filter
+	re *regexp.Regexp
+}
+
+type filterPost struct {
+	FeedID      uuid.UUID
+	Title       string
+	Description string
+	URL         string
+	Author      string
+	Categories  []string
+}
+
+type filterVerdict struct {
+	hide bool
+	read bool
+	star bool
+}
+
+// compileFilters skips rules that no longer compile instead of failing the
+// whole scrape or browse because of one bad rule.
+func compileFilters(filters []database.Filter) []filterRule {
+	rules := make([]filterRule, 0, len(filters))
+	for _, f := range filters {
+		rule := filterRule{Filter: f}
+		if f.MatchType == "regex" {
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				log.Printf("skipping filter %s: %v", shortID(f.ID), err)
+				continue
+			}
+			rule.re = re
+		} else {
+			rule.Pattern = strings.ToLower(f.Pattern)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r filterRule) appliesTo(p filterPost) bool {
+	return !r.FeedID.Valid || r.FeedID.UUID == p.FeedID
+}
+
+func (r filterRule) matches(p filterPost) bool {
+	var fields []string
+	switch r.Field {
+	case "title":
+		fields = []string{p.Title}
+	case "description":
+		fields = []string{p.Description}
+	case "url":
+		fields = []string{p.URL}
+	case "author":
+		fields = []string{p.Author}
+	case "category":
+		fields = p.Categories
+	default:
+		fields = append([]string{p.Title, p.Description, p.URL, p.Author}, p.Categories...)
+	}
+	for _, text := range fields {
+		if r.re != nil && r.re.MatchString(text) {
+			return true
+		}
+		if r.re == nil && strings.Contains(strings.ToLower(text), r.Pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateFilters applies one user's rules to a post. Exclude rules hide
+// matching posts; once any include rule applies to the post's feed, posts
+// that match none of them are hidden too.
+func evaluateFilters(rules []filterRule, p filterPost) filterVerdict {
+	var v filterVerdict
+	hasInclude, included := false, false
+	for _, r := range rules {
+		if !r.appliesTo(p) {
+			continue
+		}
+		if r.Action == "include" {
+			hasInclude = true
+		}
+		if !r.matches(p) {
+			continue
+		}
+		switch r.Action {
+		case "include":
+			included = true
+		case "exclude":
+			v.hide = true
+		case "read":
+			v.read = true
+		case "star":
+			v.star = true
+		}
+	}
+	if hasInclude && !included {
+		v.hide = true
+	}
+	return v
+}
+
+func userFilters(ctx context.Context, s *state, user database.User) ([]filterRule, error) {
+	rows, err := s.db.ListFiltersForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filters: %w", err)
+	}
+	filters := make([]database.Filter, len(rows))
+	for i, r := range rows {
+		filters[i] = database.Filter{
+			ID:        r.ID,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+			UserID:    r.UserID,
+			FeedID:    r.FeedID,
+			Field:     r.Field,
+			MatchType: r.MatchType,
+			Pattern:   r.Pattern,
+			Action:    r.Action,
+		}
+	}
+	return compileFilters(filters), nil
+}
+
+func toFilterPost(post database.Post) filterPost {
+	return filterPost{
+		FeedID:      post.FeedID,
+		Title:       post.Title,
+		Description: post.Description,
+		URL:         post.Url,
+		Author:      post.Author.String,
+		Categories:  post.Categories,
+	}
+}
+
+// followerFilters loads the rules of everyone following the feed, keyed by
+// user, once per scrape.
+func followerFilters(ctx context.Context, s *state, feedID uuid.UUID) (map[uuid.UUID][]filterRule, error) {
+	filters, err := s.db.GetFiltersForFeedFollowers(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[uuid.UUID][]filterRule)
+	for _, r := range compileFilters(filters) {
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+	return byUser, nil
+}
+
+// applyIngestFilters records the outcome of each follower's rules for a
+// newly stored post. Hidden posts are marked read so that they don't show
+// up as unread anywhere.
+func applyIngestFilters(ctx context.Context, s *state, byUser map[uuid.UUID][]filterRule, post database.Post) error {
+	p := toFilterPost(post)
+	for userID, rules := range byUser {
+		v := evaluateFilters(rules, p)
+		if v.read || v.hide {
+			err := s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UserID:    userID,
+				PostID:    post.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to mark post read: %w", err)
+			}
+		}
+		if v.star {
+			err := s.db.SetPostStarred(ctx, database.SetPostStarredParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UserID:    userID,
+				PostID:    post.ID,
+				Starred:   true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to star post: %w", err)
+			}
+		}
+	}
+	return nil
+}
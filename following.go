@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+func handlerFollowing(s *state, cmd command, user database.User) error {
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	tags, err := tagsByFollow(context.Background(), s, user)
+	if err != nil {
+		return err
+	}
+
+	tag := cmd.stringFlag("tag")
+	if tag != "" {
+		follows = slices.DeleteFunc(follows, func(f database.GetFeedFollowsForUserRow) bool {
+			return !slices.Contains(tags[f.ID], tag)
+		})
+	}
+
+	err = sortFollows(follows, cmd.stringFlag("sort"))
+	if err != nil {
+		return err
+	}
+
+	if len(follows) == 0 {
+		fmt.Println("You are not following any feeds.")
+		return nil
+	}
+
+	for _, f := range follows {
+		name := followTitle(f.Title, f.FeedName)
+		if len(tags[f.ID]) > 0 {
+			fmt.Printf("Name: %s [%s]\n", name, strings.Join(tags[f.ID], ", "))
+		} else {
+			fmt.Printf("Name: %s\n", name)
+		}
+		fmt.Printf("  URL:          %s\n", f.FeedUrl)
+		fmt.Printf("  Followed:     %s\n", f.CreatedAt.Format(time.DateOnly))
+		lastSuccess := "never"
+		if f.LastSucceededAt.Valid {
+			lastSuccess = f.LastSucceededAt.Time.Format(time.DateTime)
+		}
+		fmt.Printf("  Last success: %s\n", lastSuccess)
+		fmt.Printf("  Health:       %s\n", feedHealth(f))
+		fmt.Printf("  Posts:        %d total, %d unread, %d in the last 30 days\n", f.TotalPosts, f.UnreadPosts, f.RecentPosts)
+		if f.Notes.Valid {
+			fmt.Printf("  Notes:        %s\n", f.Notes.String)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// sortFollows orders follows in place. The query already returns them in
+// the order they were followed.
+func sortFollows(follows []database.GetFeedFollowsForUserRow, by string) error {
+	switch by {
+	case "", "followed":
+	case "name":
+		slices.SortStableFunc(follows, func(a, b database.GetFeedFollowsForUserRow) int {
+			return cmp.Compare(strings.ToLower(followTitle(a.Title, a.FeedName)), strings.ToLower(followTitle(b.Title, b.FeedName)))
+		})
+	case "activity":
+		// Most active first, so dead subscriptions end up at the bottom.
+		slices.SortStableFunc(follows, func(a, b database.GetFeedFollowsForUserRow) int {
+			return cmp.Or(
+				cmp.Compare(b.RecentPosts, a.RecentPosts),
+				cmp.Compare(b.LastSucceededAt.Time.Unix(), a.LastSucceededAt.Time.Unix()),
+				cmp.Compare(b.TotalPosts, a.TotalPosts),
+			)
+		})
+	default:
+		return fmt.Errorf("invalid sort %q: want followed, name or activity", by)
+	}
+	return nil
+}
+
+func feedHealth(f database.GetFeedFollowsForUserRow) string {
+	switch {
+	case !f.LastFetchedAt.Valid:
+		return "not fetched yet"
+	case f.NextFetchAt.Valid && f.NextFetchAt.Time.After(time.Now()):
+		return fmt.Sprintf("paused by the site until %s: %s", f.NextFetchAt.Time.Format("2006-01-02 15:04"), f.LastError.String)
+	case f.ConsecutiveFailures > 0:
+		return fmt.Sprintf("failing (%d in a row): %s", f.ConsecutiveFailures, f.LastError.String)
+	default:
+		return "ok"
+	}
+}
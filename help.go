@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func (c *commands) handlerHelp(s *state, cmd command) error {
+	if len(cmd.args) > 0 {
+		rc, ok := c.handlers[cmd.args[0]]
+		if !ok {
+			return c.run(s, command{name: cmd.args[0]})
+		}
+		rc.printHelp()
+		return nil
+	}
+	c.printOverview()
+	return nil
+}
+
+func (c *commands) printOverview() {
+	names := make([]string, 0, len(c.handlers))
+	width := 0
+	for _, name := range c.visibleNames() {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+
+	fmt.Println("usage: gator [--profile <name>] <command> [args...]")
+	fmt.Println()
+	fmt.Println("commands:")
+	for _, name := range names {
+		fmt.Printf("  %-*s  %s\n", width, name, c.handlers[name].help.description)
+	}
+	fmt.Println()
+	fmt.Println(`run "gator help <command>" or "gator <command> --help" for details`)
+}
+
+func (rc registeredCommand) usageLines() []string {
+	if len(rc.help.usage) > 0 {
+		lines := make([]string, 0, len(rc.help.usage))
+		for _, u := range rc.help.usage {
+			lines = append(lines, strings.TrimSpace(rc.name+" "+u))
+		}
+		return lines
+	}
+	parts := []string{rc.name}
+	if rc.help.flags != nil {
+		parts = append(parts, "[flags]")
+	}
+	for _, a := range rc.help.args {
+		if a.optional {
+			parts = append(parts, "["+a.name+"]")
+		} else {
+			parts = append(parts, "<"+a.name+">")
+		}
+	}
+	return []string{strings.Join(parts, " ")}
+}
+
+func (rc registeredCommand) usageText() string {
+	lines := rc.usageLines()
+	if len(lines) == 1 {
+		return "usage: " + lines[0]
+	}
+	return "usage:\n  " + strings.Join(lines, "\n  ")
+}
+
+func (rc registeredCommand) printHelp() {
+	fmt.Println(rc.usageText())
+	fmt.Println()
+	fmt.Println(rc.help.description)
+
+	described := false
+	for _, a := range rc.help.args {
+		if a.description != "" {
+			described = true
+		}
+	}
+	if described {
+		fmt.Println()
+		fmt.Println("arguments:")
+		for _, a := range rc.help.args {
+			if a.description != "" {
+				fmt.Printf("  %-12s %s\n", a.name, a.description)
+			}
+		}
+	}
+
+	fs := rc.newFlagSet()
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if !hasFlags {
+		return
+	}
+	fmt.Println()
+	fmt.Println("flags:")
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		if name != "" {
+			name = "--" + f.Name + " " + name
+		} else {
+			name = "--" + f.Name
+		}
+		line := fmt.Sprintf("  %-18s %s", name, usage)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" {
+			line += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Println(line)
+	})
+}
+
+// suggest returns the registered command closest to name, or "" when
+// nothing is close enough to be a plausible typo.
+func (c *commands) suggest(name string) string {
+	best, bestDist := "", 3
+	for _, candidate := range c.visibleNames() {
+		d := levenshtein(name, candidate)
+		if len(name) >= 3 && strings.HasPrefix(candidate, name) {
+			d = min(d, 1)
+		}
+		if d < bestDist || (d == bestDist && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
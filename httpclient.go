@@ -0,0 +1,215 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/config"
+	"github.com/andybalholm/brotli"
+	"github.com/google/uuid"
+)
+
+// Version is sent in the User-Agent. Release builds set it with
+// -ldflags "-X main.Version=v1.2.3".
+var Version = "dev"
+
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultFetchTimeout   = 30 * time.Second
+	defaultMaxResponseMB  = 10
+	projectURL            = "https://github.com/Maxeminator/blog-aggregator"
+)
+
+// Accept headers for the two kinds of documents gator reads.
+const (
+	acceptFeed = "application/rss+xml, application/atom+xml;q=0.9, application/xml;q=0.8, text/xml;q=0.8, */*;q=0.1"
+	acceptPage = "text/html, application/xhtml+xml;q=0.9, */*;q=0.1"
+)
+
+// httpClient fetches feeds and pages. downloadClient shares its transport
+// but has no overall timeout, since an episode can take a long time to
+// download. Both are replaced by configureHTTP once the config is read.
+var httpClient, downloadClient = newHTTPClients(defaultConnectTimeout, defaultFetchTimeout, http.ProxyFromEnvironment)
+
+var (
+	maxResponseBytes int64 = defaultMaxResponseMB << 20
+	// downloadIdleTimeout ends an episode download that stalls.
+	downloadIdleTimeout = defaultFetchTimeout
+	userAgent           = fmt.Sprintf("gator/%s (+%s)", Version, projectURL)
+)
+
+// configureHTTP applies the http section of the config.
+func configureHTTP(cfg config.HTTP) error {
+	connectTimeout, err := parseTimeout("connect_timeout", cfg.ConnectTimeout, defaultConnectTimeout)
+	if err != nil {
+		return err
+	}
+	timeout, err := parseTimeout("timeout", cfg.Timeout, defaultFetchTimeout)
+	if err != nil {
+		return err
+	}
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid http.proxy %q in config", cfg.Proxy)
+		}
+		proxy = http.ProxyURL(u)
+	}
+	if cfg.MaxResponseMB < 0 {
+		return fmt.Errorf("invalid http.max_response_mb %d in config", cfg.MaxResponseMB)
+	}
+	if cfg.MaxRequestsPerSecond < 0 {
+		return fmt.Errorf("invalid http.max_requests_per_second %g in config", cfg.MaxRequestsPerSecond)
+	}
+	hostInterval := defaultHostInterval
+	if cfg.HostInterval != "" {
+		hostInterval, err = time.ParseDuration(cfg.HostInterval)
+		if err != nil || hostInterval < 0 {
+			return fmt.Errorf("invalid http.host_interval %q in config: want a duration such as 1s", cfg.HostInterval)
+		}
+	}
+
+	httpClient, downloadClient = newHTTPClients(connectTimeout, timeout, proxy)
+	downloadIdleTimeout = timeout
+	maxResponseBytes = defaultMaxResponseMB << 20
+	if cfg.MaxResponseMB > 0 {
+		maxResponseBytes = cfg.MaxResponseMB << 20
+	}
+	contact := projectURL
+	if cfg.Contact != "" {
+		contact = cfg.Contact
+	}
+	userAgent = fmt.Sprintf("gator/%s (+%s)", Version, contact)
+	limiter = newRateLimiter(cfg.MaxRequestsPerSecond, hostInterval)
+	robots = nil
+	if cfg.Robots {
+		robots = newRobotsCache()
+	}
+	return nil
+}
+
+func parseTimeout(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid http.%s %q in config: want a duration such as 30s", name, value)
+	}
+	return d, nil
+}
+
+func newHTTPClients(connectTimeout, timeout time.Duration, proxy func(*http.Request) (*url.URL, error)) (*http.Client, *http.Client) {
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: timeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, &http.Client{Transport: transport}
+}
+
+// newRequest builds a GET request with the headers every download from a
+// feed site carries.
+func newRequest(ctx context.Context, url, accept string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", accept)
+	return req, nil
+}
+
+// fetchURL downloads a feed or page. header holds extra headers for the
+// request, which override the defaults. Compressed responses are decoded
+// here rather than by the transport so that brotli works too, and the
+// size limit applies to the decoded body. A 429 or 503 with a Retry-After
+// header comes back as a *retryAfterError.
+func fetchURL(ctx context.Context, url, accept string, header http.Header) ([]byte, error) {
+	req, err := newRequest(ctx, url, accept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept-Encoding", "gzip, br")
+
+	res, err := doRequest(httpClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		if until, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			return nil, &retryAfterError{status: res.StatusCode, until: until}
+		}
+	}
+	if res.StatusCode > 299 {
+		return nil, fmt.Errorf("response failed with status code: %d ", res.StatusCode)
+	}
+	if res.ContentLength > maxResponseBytes {
+		return nil, fmt.Errorf("response is larger than %d MB", maxResponseBytes>>20)
+	}
+
+	var body io.Reader
+	switch encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		body = res.Body
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress response: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	case "br":
+		body = brotli.NewReader(res.Body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(data)) > maxResponseBytes {
+		return nil, fmt.Errorf("response is larger than %d MB", maxResponseBytes>>20)
+	}
+	return data, nil
+}
+
+// loadFeedHeaders returns the custom request headers set for a feed with
+// feed set-header. Credentials only come from feed auth, so a credential
+// header that got into feed_headers anyway is not sent.
+func loadFeedHeaders(ctx context.Context, s *state, feedID uuid.UUID) (http.Header, error) {
+	rows, err := s.db.GetFeedHeaders(ctx, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed headers: %w", err)
+	}
+	header := make(http.Header)
+	for _, h := range rows {
+		if credentialHeaders[http.CanonicalHeaderKey(h.Name)] {
+			continue
+		}
+		header.Set(h.Name, h.Value)
+	}
+	return header, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/config"
+)
+
+func handlerInit(s *state, cmd command) error {
+	dbURL := cmd.stringFlag("db-url")
+	migrate := cmd.boolFlag("migrate")
+
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	if dbURL != "" {
+		err = s.cfg.SetDBURL(dbURL)
+		if err != nil {
+			return fmt.Errorf("failed to save db url: %w", err)
+		}
+	}
+	url := s.cfg.DBUrl
+	if url == "" {
+		url = defaultDBURL
+	}
+	fmt.Printf("config:  %s (profile %s)\n", path, s.cfg.Profile())
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return fmt.Errorf("can't open db: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	pingCtx, cancelPing := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelPing()
+	err = db.PingContext(pingCtx)
+	if err != nil {
+		fmt.Println("database: not reachable")
+		return fmt.Errorf("can't connect to the database (set it with: gator init --db-url <url>): %w", err)
+	}
+	fmt.Println("database: connected")
+
+	if migrate {
+		applied, err := runMigrations(ctx, db)
+		for _, m := range applied {
+			fmt.Printf("applied: %s\n", m.name)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("migrations: up to date")
+	} else {
+		pending, err := pendingMigrations(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to check migrations: %w", err)
+		}
+		if len(pending) > 0 {
+			fmt.Printf("migrations: %d pending, apply them with: gator init --migrate\n", len(pending))
+			return nil
+		}
+		fmt.Println("migrations: up to date")
+	}
+
+	fmt.Println()
+	fmt.Println("next steps:")
+	fmt.Println("  gator register <username>")
+	fmt.Println("  gator addfeed <name> <url>")
+	fmt.Println("  gator agg 1m")
+	return nil
+}
@@ -2,17 +2,110 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
+const DefaultProfile = "default"
+
+// Config holds the settings of the profile in use. DBUrl and
+// CurrentUserName always reflect that profile; SetUser writes back into it.
 type Config struct {
+	DBUrl           string
+	CurrentUserName string
+	LoginToken      string
+	SecretKey       string
+	Downloads       Downloads
+	HTTP            HTTP
+
+	active   string
+	selected string
+	profiles map[string]Profile
+}
+
+type Profile struct {
 	DBUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// LoginToken proves that CurrentUserName logged in on this machine.
+	// Its hash is stored with the user in the database.
+	LoginToken string `json:"login_token,omitempty"`
+	// SecretKey is the base64 key that feed credentials in this profile's
+	// database are encrypted with.
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+// Downloads configures where podcast episodes are saved. The settings are
+// per machine rather than per profile.
+type Downloads struct {
+	// Dir defaults to $XDG_DATA_HOME/gator/downloads, or
+	// ~/.local/share/gator/downloads when XDG_DATA_HOME is unset.
+	Dir string `json:"dir,omitempty"`
+	// MaxMB caps the total size of Dir in megabytes; 0 means no limit.
+	MaxMB int64 `json:"max_mb,omitempty"`
+}
+
+// Directory returns Dir or its default.
+func (d Downloads) Directory() (string, error) {
+	if d.Dir != "" {
+		return d.Dir, nil
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gator", "downloads"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "gator", "downloads"), nil
+}
+
+// HTTP configures how feeds, pages and episodes are downloaded. Like
+// Downloads it applies to every profile. Empty fields use the defaults.
+type HTTP struct {
+	// ConnectTimeout and Timeout are durations such as "10s". Timeout
+	// covers a whole feed or page fetch, but not episode downloads.
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	Timeout        string `json:"timeout,omitempty"`
+	// MaxResponseMB limits the size of a feed or page after decompression.
+	MaxResponseMB int64 `json:"max_response_mb,omitempty"`
+	// Proxy is a proxy URL; when empty the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables are used.
+	Proxy string `json:"proxy,omitempty"`
+	// Contact is a URL or email address that site owners can use to reach
+	// whoever runs gator. It is sent in the User-Agent.
+	Contact string `json:"contact,omitempty"`
+	// MaxRequestsPerSecond limits requests to all hosts together; 0 means
+	// no limit.
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second,omitempty"`
+	// HostInterval is the least time between two requests to one host,
+	// "1s" by default.
+	HostInterval string `json:"host_interval,omitempty"`
+	// Robots makes gator skip URLs that robots.txt disallows.
+	Robots bool `json:"robots,omitempty"`
 }
 
+// fileConfig is the on-disk layout. Version 0 files were written before
+// profiles existed and only have the top-level db_url and
+// current_user_name; migrate turns those into the "default" profile.
+type fileConfig struct {
+	Version         int                `json:"version"`
+	DBUrl           string             `json:"db_url,omitempty"`
+	CurrentUserName string             `json:"current_user_name,omitempty"`
+	ActiveProfile   string             `json:"active_profile,omitempty"`
+	Profiles        map[string]Profile `json:"profiles,omitempty"`
+	Downloads       Downloads          `json:"downloads,omitzero"`
+	HTTP            HTTP               `json:"http,omitzero"`
+}
+
+const currentVersion = 1
+
 const configFileName = ".gatorconfig.json"
 
+// getConfigFilePath keeps using ~/.gatorconfig.json when it exists so that
+// existing installs are not affected; new installs go under
+// $XDG_CONFIG_HOME/gator when that is set.
 func getConfigFilePath() (string, error) {
 	var path string
 	home, err := os.UserHomeDir()
@@ -21,46 +114,297 @@ func getConfigFilePath() (string, error) {
 	}
 
 	path = filepath.Join(home, configFileName)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		path = filepath.Join(xdg, "gator", "config.json")
+	}
 
 	return path, nil
 }
 
+// Path returns the location of the config file, whether or not it exists yet.
+func Path() (string, error) {
+	return getConfigFilePath()
+}
+
+// Create writes a config with a single default profile. It refuses to
+// overwrite an existing file.
+func Create(dbURL string) error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("config file %s already exists", path)
+	}
+	return writeFile(path, fileConfig{
+		ActiveProfile: DefaultProfile,
+		Profiles: map[string]Profile{
+			DefaultProfile: {DBUrl: dbURL},
+		},
+	})
+}
+
 func Read(cfg *Config) error {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return err
 	}
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	cfg.load(file)
+	return cfg.UseProfile(cfg.active)
+}
+
+func readFile(path string) (fileConfig, error) {
+	var file fileConfig
 	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	err = json.Unmarshal(bytes, &file)
+	if err != nil {
+		return file, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	err = migrate(&file)
+	if err != nil {
+		return file, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+func migrate(file *fileConfig) error {
+	if file.Version > currentVersion {
+		return fmt.Errorf("version %d is newer than this gator understands (%d)", file.Version, currentVersion)
+	}
+	if file.Version == 0 {
+		if len(file.Profiles) == 0 {
+			file.Profiles = map[string]Profile{
+				DefaultProfile: {DBUrl: file.DBUrl, CurrentUserName: file.CurrentUserName},
+			}
+		}
+		file.DBUrl = ""
+		file.CurrentUserName = ""
+		file.Version = 1
+	}
+
+	if file.ActiveProfile == "" {
+		file.ActiveProfile = DefaultProfile
+	}
+	for name := range file.Profiles {
+		if name == "" {
+			return fmt.Errorf("profile with an empty name")
+		}
+	}
+	if _, ok := file.Profiles[file.ActiveProfile]; !ok {
+		return fmt.Errorf("active profile %q does not exist", file.ActiveProfile)
+	}
+	return nil
+}
+
+func (cfg *Config) load(file fileConfig) {
+	cfg.active = file.ActiveProfile
+	cfg.profiles = file.Profiles
+	cfg.Downloads = file.Downloads
+	cfg.HTTP = file.HTTP
+}
+
+// update applies change to the config as it currently is on disk, not to
+// the copy read at startup, so that concurrent gator processes don't undo
+// each other's changes. The file is locked for the whole read-modify-write.
+func (cfg *Config) update(change func(file *fileConfig) error) error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	err = change(&file)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(bytes, &cfg)
+	err = writeFile(path, file)
 	if err != nil {
 		return err
 	}
+	cfg.load(file)
 	return nil
 }
 
-func write(cfg *Config) error {
-	bytes, err := json.Marshal(cfg)
+// writeFile replaces the config atomically: a crash leaves either the old
+// or the new file, never a truncated one. The file may hold a database
+// password, so it is only readable by its owner.
+func writeFile(path string, file fileConfig) error {
+	file.Version = currentVersion
+	bytes, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	path, err := getConfigFilePath()
+	tmp, err := os.CreateTemp(filepath.Dir(path), configFileName+".*.tmp")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
 
-	err = os.WriteFile(path, bytes, 0644)
+	err = tmp.Chmod(0600)
+	if err == nil {
+		_, err = tmp.Write(bytes)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.Rename(tmp.Name(), path)
 }
 
-func (cfg *Config) SetUser(name string) error {
+// SetUser saves the logged in user together with the token from login.
+func (cfg *Config) SetUser(name, token string) error {
+	selected := cfg.selected
+	err := cfg.update(func(file *fileConfig) error {
+		p, ok := file.Profiles[selected]
+		if !ok {
+			return fmt.Errorf("profile %q does not exist", selected)
+		}
+		p.CurrentUserName = name
+		p.LoginToken = token
+		file.Profiles[selected] = p
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 	cfg.CurrentUserName = name
-	return write(cfg)
+	cfg.LoginToken = token
+	return nil
+}
+
+func (cfg *Config) SetDBURL(dbURL string) error {
+	selected := cfg.selected
+	err := cfg.update(func(file *fileConfig) error {
+		p, ok := file.Profiles[selected]
+		if !ok {
+			return fmt.Errorf("profile %q does not exist", selected)
+		}
+		p.DBUrl = dbURL
+		file.Profiles[selected] = p
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cfg.DBUrl = dbURL
+	return nil
+}
+
+func (cfg *Config) SetSecretKey(key string) error {
+	selected := cfg.selected
+	err := cfg.update(func(file *fileConfig) error {
+		p, ok := file.Profiles[selected]
+		if !ok {
+			return fmt.Errorf("profile %q does not exist", selected)
+		}
+		p.SecretKey = key
+		file.Profiles[selected] = p
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cfg.SecretKey = key
+	return nil
+}
+
+// UseProfile switches to the named profile for this process only, as the
+// --profile flag does. Use SetActiveProfile to make the choice stick.
+func (cfg *Config) UseProfile(name string) error {
+	p, ok := cfg.profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	cfg.selected = name
+	cfg.DBUrl = p.DBUrl
+	cfg.CurrentUserName = p.CurrentUserName
+	cfg.LoginToken = p.LoginToken
+	cfg.SecretKey = p.SecretKey
+	return nil
+}
+
+func (cfg *Config) SetActiveProfile(name string) error {
+	err := cfg.update(func(file *fileConfig) error {
+		if _, ok := file.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		file.ActiveProfile = name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.UseProfile(name)
+}
+
+func (cfg *Config) AddProfile(name, dbURL string) error {
+	if name == "" {
+		return fmt.Errorf("profile name can't be empty")
+	}
+	return cfg.update(func(file *fileConfig) error {
+		if _, ok := file.Profiles[name]; ok {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+		file.Profiles[name] = Profile{DBUrl: dbURL}
+		return nil
+	})
+}
+
+// Profile returns the name of the profile in use.
+func (cfg *Config) Profile() string {
+	return cfg.selected
+}
+
+// ActiveProfile returns the name of the profile used when --profile is not given.
+func (cfg *Config) ActiveProfile() string {
+	return cfg.active
+}
+
+func (cfg *Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.profiles))
+	for name := range cfg.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cfg *Config) GetProfile(name string) (Profile, bool) {
+	p, ok := cfg.profiles[name]
+	return p, ok
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    fileConfig
+		want    fileConfig
+		wantErr bool
+	}{
+		{
+			name: "version 0 becomes the default profile",
+			file: fileConfig{DBUrl: "postgres://db", CurrentUserName: "alice"},
+			want: fileConfig{
+				Version:       1,
+				ActiveProfile: DefaultProfile,
+				Profiles:      map[string]Profile{DefaultProfile: {DBUrl: "postgres://db", CurrentUserName: "alice"}},
+			},
+		},
+		{
+			name: "version 0 with profiles keeps them",
+			file: fileConfig{DBUrl: "postgres://old", Profiles: map[string]Profile{DefaultProfile: {DBUrl: "postgres://new"}}},
+			want: fileConfig{
+				Version:       1,
+				ActiveProfile: DefaultProfile,
+				Profiles:      map[string]Profile{DefaultProfile: {DBUrl: "postgres://new"}},
+			},
+		},
+		{
+			name: "version 1 is unchanged",
+			file: fileConfig{
+				Version:       1,
+				ActiveProfile: "work",
+				Profiles:      map[string]Profile{"work": {DBUrl: "postgres://work", LoginToken: "t"}},
+			},
+			want: fileConfig{
+				Version:       1,
+				ActiveProfile: "work",
+				Profiles:      map[string]Profile{"work": {DBUrl: "postgres://work", LoginToken: "t"}},
+			},
+		},
+		{
+			name:    "newer version",
+			file:    fileConfig{Version: currentVersion + 1, Profiles: map[string]Profile{DefaultProfile: {}}},
+			wantErr: true,
+		},
+		{
+			name:    "missing active profile",
+			file:    fileConfig{Version: 1, ActiveProfile: "work", Profiles: map[string]Profile{DefaultProfile: {}}},
+			wantErr: true,
+		},
+		{
+			name:    "empty profile name",
+			file:    fileConfig{Version: 1, Profiles: map[string]Profile{DefaultProfile: {}, "": {}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.file
+			err := migrate(&file)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("migrate() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate(): %v", err)
+			}
+			got, _ := json.Marshal(file)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("migrate() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReadLegacyFileAndWriteBack(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	path := filepath.Join(home, configFileName)
+	err := os.WriteFile(path, []byte(`{"db_url": "postgres://db", "current_user_name": "alice"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	err = Read(&cfg)
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if cfg.DBUrl != "postgres://db" || cfg.CurrentUserName != "alice" {
+		t.Fatalf("Read() = db %q user %q, want the legacy values", cfg.DBUrl, cfg.CurrentUserName)
+	}
+
+	err = cfg.SetUser("bob", "token")
+	if err != nil {
+		t.Fatalf("SetUser(): %v", err)
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file fileConfig
+	err = json.Unmarshal(bytes, &file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != currentVersion || file.DBUrl != "" || file.CurrentUserName != "" {
+		t.Errorf("written file still has the legacy layout: %s", bytes)
+	}
+	if p := file.Profiles[DefaultProfile]; p.CurrentUserName != "bob" || p.LoginToken != "token" || p.DBUrl != "postgres://db" {
+		t.Errorf("default profile = %+v", p)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockAge  time.Duration // age of a leftover lock file; 0 for none
+		maxDelay time.Duration
+	}{
+		{name: "no lock", maxDelay: time.Second},
+		{name: "stale lock is broken", lockAge: lockStale + time.Minute, maxDelay: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			lockPath := path + ".lock"
+			if tt.lockAge > 0 {
+				err := os.WriteFile(lockPath, []byte("12345\n"), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				old := time.Now().Add(-tt.lockAge)
+				err = os.Chtimes(lockPath, old, old)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			start := time.Now()
+			unlock, err := lockFile(path)
+			if err != nil {
+				t.Fatalf("lockFile(): %v", err)
+			}
+			if d := time.Since(start); d > tt.maxDelay {
+				t.Errorf("lockFile() took %v", d)
+			}
+			if _, err := os.Stat(lockPath); err != nil {
+				t.Errorf("lock file missing while held: %v", err)
+			}
+			unlock()
+			if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+				t.Errorf("lock file still there after unlock: %v", err)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,19 +15,21 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id) VALUES
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, title, notes) VALUES
     (
         $1,
         $2,
         $3,
         $4,
-        $5
+        $5,
+        $6,
+        $7
     )
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, title, notes
 )
 
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.title, inserted_feed_follow.notes,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -40,6 +43,8 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+	Notes     sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -48,6 +53,8 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+	Notes     sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -59,6 +66,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Title,
+		arg.Notes,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -67,50 +76,222 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Title,
+		&i.Notes,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
+const deleteFeedFollows = `-- name: DeleteFeedFollows :execrows
+DELETE FROM feed_follows
+`
+
+func (q *Queries) DeleteFeedFollows(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollows)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAllFeedFollows = `-- name: GetAllFeedFollows :many
+SELECT id, created_at, updated_at, user_id, feed_id, title, notes FROM feed_follows
+`
+
+func (q *Queries) GetAllFeedFollows(ctx context.Context) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeedFollows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFollow
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+			&i.Title,
+			&i.Notes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, title, notes FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+		&i.Notes,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT
-  ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id,
+  ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id, ff.title, ff.notes,
   u.name AS user_name,
-  f.name AS feed_name
+  f.name AS feed_name,
+  f.url AS feed_url,
+  f.last_fetched_at,
+  f.last_succeeded_at,
+  f.last_error,
+  f.consecutive_failures,
+  f.next_fetch_at,
+  COUNT(p.id) AS total_posts,
+  COUNT(p.id) FILTER (WHERE ps.read_at IS NULL) AS unread_posts,
+  COUNT(p.id) FILTER (WHERE p.published_at > NOW() - INTERVAL '30 days') AS recent_posts
 FROM feed_follows ff
 JOIN users u ON ff.user_id = u.id
 JOIN feeds f ON ff.feed_id = f.id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
 WHERE ff.user_id = $1
+GROUP BY ff.id, u.id, f.id
+ORDER BY ff.created_at, ff.id
 `
 
 type GetFeedFollowsForUserRow struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	UserID              uuid.UUID
+	FeedID              uuid.UUID
+	Title               sql.NullString
+	Notes               sql.NullString
+	UserName            string
+	FeedName            string
+	FeedUrl             string
+	LastFetchedAt       sql.NullTime
+	LastSucceededAt     sql.NullTime
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	NextFetchAt         sql.NullTime
+	TotalPosts          int64
+	UnreadPosts         int64
+	RecentPosts         int64
+}
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedFollowsForUserRow
+	for rows.Next() {
+		var i GetFeedFollowsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+			&i.Title,
+			&i.Notes,
+			&i.UserName,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.LastFetchedAt,
+			&i.LastSucceededAt,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.NextFetchAt,
+			&i.TotalPosts,
+			&i.UnreadPosts,
+			&i.RecentPosts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedFollowsForUser = `-- name: ListFeedFollowsForUser :many
+SELECT
+  ff.id, ff.created_at, ff.updated_at, ff.user_id, ff.feed_id, ff.title, ff.notes,
+  f.name AS feed_name,
+  f.url AS feed_url
+FROM feed_follows ff
+JOIN feeds f ON ff.feed_id = f.id
+WHERE ff.user_id = $1
+ORDER BY ff.created_at, ff.id
+LIMIT $2 OFFSET $3
+`
+
+type ListFeedFollowsForUserParams struct {
+	UserID uuid.UUID
+	Limit  int32
+	Offset int32
+}
+
+type ListFeedFollowsForUserRow struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
-	UserName  string
+	Title     sql.NullString
+	Notes     sql.NullString
 	FeedName  string
+	FeedUrl   string
 }
 
-func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
+func (q *Queries) ListFeedFollowsForUser(ctx context.Context, arg ListFeedFollowsForUserParams) ([]ListFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedFollowsForUser, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetFeedFollowsForUserRow
+	var items []ListFeedFollowsForUserRow
 	for rows.Next() {
-		var i GetFeedFollowsForUserRow
+		var i ListFeedFollowsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
-			&i.UserName,
+			&i.Title,
+			&i.Notes,
 			&i.FeedName,
+			&i.FeedUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -125,6 +306,40 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
+const setFeedFollowNotes = `-- name: SetFeedFollowNotes :exec
+UPDATE feed_follows
+SET notes = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedFollowNotesParams struct {
+	Notes     sql.NullString
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetFeedFollowNotes(ctx context.Context, arg SetFeedFollowNotesParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowNotes, arg.Notes, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedFollowTitle = `-- name: SetFeedFollowTitle :exec
+UPDATE feed_follows
+SET title = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedFollowTitleParams struct {
+	Title     sql.NullString
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetFeedFollowTitle(ctx context.Context, arg SetFeedFollowTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowTitle, arg.Title, arg.UpdatedAt, arg.ID)
+	return err
+}
+
 const unfollowUser = `-- name: UnfollowUser :exec
 DELETE FROM feed_follows
 WHERE user_id =$1 AND feed_id =$2
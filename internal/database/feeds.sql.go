@@ -23,7 +23,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_succeeded_at, last_error, consecutive_failures, fetch_full_content, auto_download, next_fetch_at
 `
 
 type CreateFeedParams struct {
@@ -53,12 +53,77 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastSucceededAt,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.FetchFullContent,
+		&i.AutoDownload,
+		&i.NextFetchAt,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteFeeds = `-- name: DeleteFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteFeeds)
+	return err
+}
+
+const getAllFeeds = `-- name: GetAllFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_succeeded_at, last_error, consecutive_failures, fetch_full_content, auto_download, next_fetch_at FROM feeds
+`
+
+func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastSucceededAt,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.FetchFullContent,
+			&i.AutoDownload,
+			&i.NextFetchAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_succeeded_at, last_error, consecutive_failures, fetch_full_content, auto_download, next_fetch_at FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -72,19 +137,44 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastSucceededAt,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.FetchFullContent,
+		&i.AutoDownload,
+		&i.NextFetchAt,
 	)
 	return i, err
 }
 
+const getFeedDeletionCounts = `-- name: GetFeedDeletionCounts :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = $1) AS posts
+`
+
+type GetFeedDeletionCountsRow struct {
+	Followers int64
+	Posts     int64
+}
+
+func (q *Queries) GetFeedDeletionCounts(ctx context.Context, feedID uuid.UUID) (GetFeedDeletionCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDeletionCounts, feedID)
+	var i GetFeedDeletionCountsRow
+	err := row.Scan(&i.Followers, &i.Posts)
+	return i, err
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_succeeded_at, last_error, consecutive_failures, fetch_full_content, auto_download, next_fetch_at
 FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp
 ORDER BY last_fetched_at NULLS FIRST, updated_at ASC
 LIMIT 1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, now time.Time) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedToFetch, now)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -94,10 +184,64 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastSucceededAt,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.FetchFullContent,
+		&i.AutoDownload,
+		&i.NextFetchAt,
 	)
 	return i, err
 }
 
+const listFeeds = `-- name: ListFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_succeeded_at, last_error, consecutive_failures, fetch_full_content, auto_download, next_fetch_at FROM feeds
+ORDER BY created_at, id
+LIMIT $1 OFFSET $2
+`
+
+type ListFeedsParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastSucceededAt,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.FetchFullContent,
+			&i.AutoDownload,
+			&i.NextFetchAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFeedsWithUsers = `-- name: ListFeedsWithUsers :many
 SELECT feeds.name, feeds.url, users.name FROM feeds
 JOIN users ON feeds.user_id=users.id
@@ -147,3 +291,156 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.ID)
 	return err
 }
+
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET last_error = $1, consecutive_failures = consecutive_failures + 1
+WHERE id = $2
+`
+
+type MarkFeedFetchFailedParams struct {
+	LastError sql.NullString
+	ID        uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed, arg.LastError, arg.ID)
+	return err
+}
+
+const markFeedFetchSucceeded = `-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET last_succeeded_at = $1, last_error = NULL, consecutive_failures = 0
+WHERE id = $2
+`
+
+type MarkFeedFetchSucceededParams struct {
+	LastSucceededAt sql.NullTime
+	ID              uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetchSucceeded(ctx context.Context, arg MarkFeedFetchSucceededParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchSucceeded, arg.LastSucceededAt, arg.ID)
+	return err
+}
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $1, updated_at = $2
+WHERE id = $3
+`
+
+type RenameFeedParams struct {
+	Name      string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.Name, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedAutoDownload = `-- name: SetFeedAutoDownload :exec
+UPDATE feeds
+SET auto_download = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedAutoDownloadParams struct {
+	AutoDownload bool
+	UpdatedAt    time.Time
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetFeedAutoDownload(ctx context.Context, arg SetFeedAutoDownloadParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedAutoDownload, arg.AutoDownload, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedFetchFullContent = `-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedFetchFullContentParams struct {
+	FetchFullContent bool
+	UpdatedAt        time.Time
+	ID               uuid.UUID
+}
+
+func (q *Queries) SetFeedFetchFullContent(ctx context.Context, arg SetFeedFetchFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchFullContent, arg.FetchFullContent, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedNextFetchAt = `-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = $2
+`
+
+type SetFeedNextFetchAtParams struct {
+	NextFetchAt sql.NullTime
+	ID          uuid.UUID
+}
+
+func (q *Queries) SetFeedNextFetchAt(ctx context.Context, arg SetFeedNextFetchAtParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchAt, arg.NextFetchAt, arg.ID)
+	return err
+}
+
+const setFeedOwner = `-- name: SetFeedOwner :exec
+UPDATE feeds
+SET user_id = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedOwnerParams struct {
+	UserID    uuid.UUID
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetFeedOwner(ctx context.Context, arg SetFeedOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedOwner, arg.UserID, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedUrlParams struct {
+	Url       string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.Url, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const transferFeedsOwnedBy = `-- name: TransferFeedsOwnedBy :execrows
+UPDATE feeds
+SET user_id = $1, updated_at = $2
+WHERE user_id = $3
+`
+
+type TransferFeedsOwnedByParams struct {
+	NewOwnerID uuid.UUID
+	UpdatedAt  time.Time
+	OldOwnerID uuid.UUID
+}
+
+func (q *Queries) TransferFeedsOwnedBy(ctx context.Context, arg TransferFeedsOwnedByParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferFeedsOwnedBy, arg.NewOwnerID, arg.UpdatedAt, arg.OldOwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -12,13 +12,27 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	LastSucceededAt     sql.NullTime
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	FetchFullContent    bool
+	AutoDownload        bool
+	NextFetchAt         sql.NullTime
+}
+
+type FeedCredential struct {
+	FeedID    uuid.UUID
+	Kind      string
+	Secret    []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type FeedFollow struct {
@@ -27,22 +41,93 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+	Notes     sql.NullString
+}
+
+type FeedFollowTag struct {
+	FeedFollowID uuid.UUID
+	TagID        uuid.UUID
+	CreatedAt    time.Time
+}
+
+type FeedHeader struct {
+	FeedID    uuid.UUID
+	Name      string
+	Value     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Filter struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Field     string
+	MatchType string
+	Pattern   string
+	Action    string
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     string
+	PublishedAt     time.Time
+	FeedID          uuid.UUID
+	Simhash         sql.NullInt64
+	ClusterID       uuid.NullUUID
+	ContentHtml     sql.NullString
+	ContentText     sql.NullString
+	DescriptionHtml sql.NullString
+	DescriptionText sql.NullString
+	Author          sql.NullString
+	Categories      []string
+	CommentsUrl     sql.NullString
+	EpisodeDuration sql.NullInt32
+	EpisodeNumber   sql.NullInt32
+	EpisodeImage    sql.NullString
 }
 
-type User struct {
+type PostEnclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  string
+	Length    sql.NullInt64
+}
+
+type PostState struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+	Starred   bool
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Name      string
 }
+
+type User struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	ApiKeyHash     sql.NullString
+	PasswordHash   sql.NullString
+	IsAdmin        bool
+	LoginTokenHash sql.NullString
+}
@@ -7,26 +7,39 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, simhash, description_html, description_text, content_html, content_text, author, categories, comments_url, episode_duration, episode_number, episode_image)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, simhash, cluster_id, content_html, content_text, description_html, description_text, author, categories, comments_url, episode_duration, episode_number, episode_image
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     string
+	PublishedAt     time.Time
+	FeedID          uuid.UUID
+	Simhash         sql.NullInt64
+	DescriptionHtml sql.NullString
+	DescriptionText sql.NullString
+	ContentHtml     sql.NullString
+	ContentText     sql.NullString
+	Author          sql.NullString
+	Categories      []string
+	CommentsUrl     sql.NullString
+	EpisodeDuration sql.NullInt32
+	EpisodeNumber   sql.NullInt32
+	EpisodeImage    sql.NullString
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -39,6 +52,17 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Simhash,
+		arg.DescriptionHtml,
+		arg.DescriptionText,
+		arg.ContentHtml,
+		arg.ContentText,
+		arg.Author,
+		pq.Array(arg.Categories),
+		arg.CommentsUrl,
+		arg.EpisodeDuration,
+		arg.EpisodeNumber,
+		arg.EpisodeImage,
 	)
 	var i Post
 	err := row.Scan(
@@ -50,33 +74,170 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Simhash,
+		&i.ClusterID,
+		&i.ContentHtml,
+		&i.ContentText,
+		&i.DescriptionHtml,
+		&i.DescriptionText,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.CommentsUrl,
+		&i.EpisodeDuration,
+		&i.EpisodeNumber,
+		&i.EpisodeImage,
 	)
 	return i, err
 }
 
+const deletePosts = `-- name: DeletePosts :execrows
+DELETE FROM posts
+`
+
+func (q *Queries) DeletePosts(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePosts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAllPosts = `-- name: GetAllPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, simhash, cluster_id, content_html, content_text, description_html, description_text, author, categories, comments_url, episode_duration, episode_number, episode_image FROM posts
+`
+
+func (q *Queries) GetAllPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Simhash,
+			&i.ClusterID,
+			&i.ContentHtml,
+			&i.ContentText,
+			&i.DescriptionHtml,
+			&i.DescriptionText,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.CommentsUrl,
+			&i.EpisodeDuration,
+			&i.EpisodeNumber,
+			&i.EpisodeImage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.simhash, posts.cluster_id, posts.content_html, posts.content_text, posts.description_html, posts.description_text, posts.author, posts.categories, posts.comments_url, posts.episode_duration, posts.episode_number, posts.episode_image,
+    COALESCE(feed_follows.title, feeds.name) AS feed_title,
+    COALESCE(post_states.starred, FALSE) AS starred
 FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+AND ($2::text IS NULL OR EXISTS (
+    SELECT 1 FROM feed_follow_tags
+    JOIN tags ON feed_follow_tags.tag_id = tags.id
+    WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND tags.name = $2
+))
+AND ($3::text IS NULL OR lower(posts.author) = lower($3))
+AND ($4::text IS NULL OR EXISTS (
+    SELECT 1 FROM unnest(posts.categories) AS category
+    WHERE lower(category) = lower($4)
+))
+AND ($5::text IS NULL
+    OR posts.title ILIKE '%' || $5 || '%'
+    OR COALESCE(posts.description_text, posts.description) ILIKE '%' || $5 || '%'
+    OR posts.content_text ILIKE '%' || $5 || '%')
+AND (NOT $6::boolean OR post_states.read_at IS NULL)
+AND ($7::uuid[] IS NULL OR posts.cluster_id = ANY($7::uuid[]))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $8 OFFSET $9
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	Tag        sql.NullString
+	Author     sql.NullString
+	Category   sql.NullString
+	Search     sql.NullString
+	UnreadOnly bool
+	ClusterIds []uuid.UUID
+	Limit      int32
+	Offset     int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type GetPostsForUserRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Title           string
+	Url             string
+	Description     string
+	PublishedAt     time.Time
+	FeedID          uuid.UUID
+	Simhash         sql.NullInt64
+	ClusterID       uuid.NullUUID
+	ContentHtml     sql.NullString
+	ContentText     sql.NullString
+	DescriptionHtml sql.NullString
+	DescriptionText sql.NullString
+	Author          sql.NullString
+	Categories      []string
+	CommentsUrl     sql.NullString
+	EpisodeDuration sql.NullInt32
+	EpisodeNumber   sql.NullInt32
+	EpisodeImage    sql.NullString
+	FeedTitle       string
+	Starred         bool
+}
+
+// Every copy of a duplicated story is returned: listPosts applies the
+// user's filters and then picks the copy that represents the cluster.
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Tag,
+		arg.Author,
+		arg.Category,
+		arg.Search,
+		arg.UnreadOnly,
+		pq.Array(arg.ClusterIds),
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -86,6 +247,20 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Simhash,
+			&i.ClusterID,
+			&i.ContentHtml,
+			&i.ContentText,
+			&i.DescriptionHtml,
+			&i.DescriptionText,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.CommentsUrl,
+			&i.EpisodeDuration,
+			&i.EpisodeNumber,
+			&i.EpisodeImage,
+			&i.FeedTitle,
+			&i.Starred,
 		); err != nil {
 			return nil, err
 		}
@@ -99,3 +274,83 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getRecentPostFingerprints = `-- name: GetRecentPostFingerprints :many
+SELECT id, feed_id, simhash, cluster_id
+FROM posts
+WHERE simhash IS NOT NULL AND created_at > $1
+`
+
+type GetRecentPostFingerprintsRow struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	Simhash   sql.NullInt64
+	ClusterID uuid.NullUUID
+}
+
+func (q *Queries) GetRecentPostFingerprints(ctx context.Context, createdAt time.Time) ([]GetRecentPostFingerprintsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostFingerprints, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentPostFingerprintsRow
+	for rows.Next() {
+		var i GetRecentPostFingerprintsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Simhash,
+			&i.ClusterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostCluster = `-- name: SetPostCluster :exec
+UPDATE posts
+SET cluster_id = $1
+WHERE id = $2
+`
+
+type SetPostClusterParams struct {
+	ClusterID uuid.NullUUID
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetPostCluster(ctx context.Context, arg SetPostClusterParams) error {
+	_, err := q.db.ExecContext(ctx, setPostCluster, arg.ClusterID, arg.ID)
+	return err
+}
+
+const setPostContent = `-- name: SetPostContent :exec
+UPDATE posts
+SET content_html = $1, content_text = $2, updated_at = $3
+WHERE id = $4
+`
+
+type SetPostContentParams struct {
+	ContentHtml sql.NullString
+	ContentText sql.NullString
+	UpdatedAt   time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) SetPostContent(ctx context.Context, arg SetPostContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostContent,
+		arg.ContentHtml,
+		arg.ContentText,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
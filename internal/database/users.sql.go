@@ -7,27 +7,45 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, api_key_hash, password_hash, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, api_key_hash, password_hash, is_admin
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	ApiKeyHash   sql.NullString
+	PasswordHash sql.NullString
+	IsAdmin      bool
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +54,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.ApiKeyHash,
+		arg.PasswordHash,
+		arg.IsAdmin,
 	)
 	var i User
 	err := row.Scan(
@@ -43,12 +64,78 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.LoginTokenHash,
+	)
+	return i, err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getOtherAdmin = `-- name: GetOtherAdmin :one
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users
+WHERE is_admin AND id <> $1
+ORDER BY created_at
+LIMIT 1
+`
+
+func (q *Queries) GetOtherAdmin(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getOtherAdmin, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.LoginTokenHash,
+	)
+	return i, err
+}
+
+const getTableCounts = `-- name: GetTableCounts :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM post_states) AS post_states
+`
+
+type GetTableCountsRow struct {
+	Users       int64
+	Feeds       int64
+	FeedFollows int64
+	Posts       int64
+	PostStates  int64
+}
+
+func (q *Queries) GetTableCounts(ctx context.Context) (GetTableCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getTableCounts)
+	var i GetTableCountsRow
+	err := row.Scan(
+		&i.Users,
+		&i.Feeds,
+		&i.FeedFollows,
+		&i.Posts,
+		&i.PostStates,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users
 WHERE name = $1
 `
 
@@ -60,12 +147,37 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.LoginTokenHash,
+	)
+	return i, err
+}
+
+const getUserByAPIKeyHash = `-- name: GetUserByAPIKeyHash :one
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users
+WHERE api_key_hash = $1
+`
+
+func (q *Queries) GetUserByAPIKeyHash(ctx context.Context, apiKeyHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAPIKeyHash, apiKeyHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.LoginTokenHash,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, name FROM users WHERE id = $1
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
@@ -76,12 +188,34 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.IsAdmin,
+		&i.LoginTokenHash,
 	)
 	return i, err
 }
 
+const getUserDeletionCounts = `-- name: GetUserDeletionCounts :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS feed_follows
+`
+
+type GetUserDeletionCountsRow struct {
+	Feeds       int64
+	FeedFollows int64
+}
+
+func (q *Queries) GetUserDeletionCounts(ctx context.Context, userID uuid.UUID) (GetUserDeletionCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserDeletionCounts, userID)
+	var i GetUserDeletionCountsRow
+	err := row.Scan(&i.Feeds, &i.FeedFollows)
+	return i, err
+}
+
 const getUsers = `-- name: GetUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -98,6 +232,109 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.ApiKeyHash,
+			&i.PasswordHash,
+			&i.IsAdmin,
+			&i.LoginTokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersWithStats = `-- name: GetUsersWithStats :many
+SELECT
+    users.id, users.created_at, users.updated_at, users.name, users.api_key_hash, users.password_hash, users.is_admin,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = users.id) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = users.id AND post_states.read_at IS NOT NULL) AS posts_read
+FROM users
+ORDER BY users.created_at
+`
+
+type GetUsersWithStatsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	ApiKeyHash     sql.NullString
+	PasswordHash   sql.NullString
+	IsAdmin        bool
+	LoginTokenHash sql.NullString
+	Follows        int64
+	PostsRead      int64
+}
+
+func (q *Queries) GetUsersWithStats(ctx context.Context) ([]GetUsersWithStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersWithStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersWithStatsRow
+	for rows.Next() {
+		var i GetUsersWithStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.ApiKeyHash,
+			&i.PasswordHash,
+			&i.IsAdmin,
+			&i.LoginTokenHash,
+			&i.Follows,
+			&i.PostsRead,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, is_admin FROM users
+ORDER BY created_at, id
+LIMIT $1 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.ApiKeyHash,
+			&i.PasswordHash,
+			&i.IsAdmin,
+			&i.LoginTokenHash,
 		); err != nil {
 			return nil, err
 		}
@@ -112,6 +349,23 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const renameUser = `-- name: RenameUser :exec
+UPDATE users
+SET name = $1, updated_at = $2
+WHERE id = $3
+`
+
+type RenameUserParams struct {
+	Name      string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	_, err := q.db.ExecContext(ctx, renameUser, arg.Name, arg.UpdatedAt, arg.ID)
+	return err
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -120,3 +374,70 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setUserAPIKeyHash = `-- name: SetUserAPIKeyHash :exec
+UPDATE users
+SET api_key_hash = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetUserAPIKeyHashParams struct {
+	ApiKeyHash sql.NullString
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetUserAPIKeyHash(ctx context.Context, arg SetUserAPIKeyHashParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAPIKeyHash, arg.ApiKeyHash, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :exec
+UPDATE users
+SET is_admin = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetUserAdminParams struct {
+	IsAdmin   bool
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAdmin, arg.IsAdmin, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserLoginTokenHash = `-- name: SetUserLoginTokenHash :exec
+UPDATE users
+SET login_token_hash = $1
+WHERE id = $2
+`
+
+type SetUserLoginTokenHashParams struct {
+	LoginTokenHash sql.NullString
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetUserLoginTokenHash(ctx context.Context, arg SetUserLoginTokenHashParams) error {
+	_, err := q.db.ExecContext(ctx, setUserLoginTokenHash, arg.LoginTokenHash, arg.ID)
+	return err
+}
+
+const setUserPasswordHash = `-- name: SetUserPasswordHash :exec
+UPDATE users
+SET password_hash = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetUserPasswordHashParams struct {
+	PasswordHash sql.NullString
+	UpdatedAt    time.Time
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetUserPasswordHash(ctx context.Context, arg SetUserPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPasswordHash, arg.PasswordHash, arg.UpdatedAt, arg.ID)
+	return err
+}
@@ -2,13 +2,26 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/Maxeminator/blog-aggregator/internal/database"
 )
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
+		if key := os.Getenv(apiKeyEnvVar); key != "" {
+			user, err := s.db.GetUserByAPIKeyHash(context.Background(), hashAPIKey(key))
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("invalid api key in %s", apiKeyEnvVar)
+			}
+			if err != nil {
+				return fmt.Errorf("can't find user %w", err)
+			}
+			return handler(s, cmd, user)
+		}
 
 		if s.cfg.CurrentUserName == "" {
 			return fmt.Errorf("no user logged in")
@@ -17,6 +30,18 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 		if err != nil {
 			return fmt.Errorf("can't find user %w", err)
 		}
+		if needsSession(user) && !validSession(user, s.cfg.LoginToken) {
+			return fmt.Errorf("session for %s is not valid, run: gator login %s", user.Name, user.Name)
+		}
 		return handler(s, cmd, user)
 	}
 }
+
+func middlewareAdmin(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+		if !user.IsAdmin {
+			return fmt.Errorf("%s requires admin rights", cmd.name)
+		}
+		return handler(s, cmd, user)
+	})
+}
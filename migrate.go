@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/schema/*.sql
+var migrationFS embed.FS
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+}
+
+// The migrations are the same goose files used in development, and the
+// bookkeeping table is goose's own, so a database set up by "gator init
+// --migrate" can keep being managed with goose and vice versa.
+const createGooseTable = `
+CREATE TABLE IF NOT EXISTS goose_db_version (
+    id serial NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp NULL DEFAULT now(),
+    PRIMARY KEY (id)
+)`
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFS.ReadDir("sql/schema")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		body, err := migrationFS.ReadFile(path.Join("sql/schema", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{
+			version: version,
+			name:    e.Name(),
+			up:      gooseUpSection(string(body)),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// gooseUpSection returns the SQL between "-- +goose Up" and "-- +goose Down".
+func gooseUpSection(body string) string {
+	_, up, ok := strings.Cut(body, "-- +goose Up")
+	if !ok {
+		return ""
+	}
+	up, _, _ = strings.Cut(up, "-- +goose Down")
+	return strings.TrimSpace(up)
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	applied := make(map[int64]bool)
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT to_regclass('goose_db_version') IS NOT NULL").Scan(&exists)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version_id FROM goose_db_version WHERE is_applied")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+func pendingMigrations(ctx context.Context, db *sql.DB) ([]migration, error) {
+	all, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []migration
+	for _, m := range all {
+		if !applied[m.version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// runMigrations applies every pending migration, each in its own
+// transaction, and returns the ones it applied.
+func runMigrations(ctx context.Context, db *sql.DB) ([]migration, error) {
+	_, err := db.ExecContext(ctx, createGooseTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create goose_db_version: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO goose_db_version (version_id, is_applied)
+		SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise goose_db_version: %w", err)
+	}
+
+	pending, err := pendingMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range pending {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return pending[:i], err
+		}
+		_, err = tx.ExecContext(ctx, m.up)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)", m.version)
+		}
+		if err != nil {
+			tx.Rollback()
+			return pending[:i], fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return pending[:i], fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+	}
+	return pending, nil
+}
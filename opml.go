@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const opmlUsage = `usage:
+  opml export [file]
+  opml import <file>`
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlOutline is either a folder (no xmlUrl, child outlines) or a feed.
+// Category holds a comma-separated list of "/tag" paths as in OPML 2.0.
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func handlerOPML(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf(opmlUsage)
+	}
+	switch cmd.args[0] {
+	case "export":
+		out := os.Stdout
+		if len(cmd.args) >= 2 {
+			f, err := os.Create(cmd.args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		return exportOPML(context.Background(), s, user, out)
+	case "import":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("usage: opml import <file>")
+		}
+		f, err := os.Open(cmd.args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return importOPML(context.Background(), s, user, f)
+	default:
+		return fmt.Errorf(opmlUsage)
+	}
+}
+
+// exportOPML puts each tagged follow in a folder named after its first tag
+// and lists all of its tags in the category attribute, so that readers
+// which only understand folders still get a sensible structure.
+func exportOPML(ctx context.Context, s *state, user database.User, w io.Writer) error {
+	follows, err := s.db.ListFeedFollowsForUser(ctx, database.ListFeedFollowsForUserParams{
+		UserID: user.ID,
+		Limit:  1 << 30,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	tags, err := tagsByFollow(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       fmt.Sprintf("gator subscriptions of %s", user.Name),
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	folders := make(map[string]int)
+	for _, f := range follows {
+		name := followTitle(f.Title, f.FeedName)
+		outline := opmlOutline{
+			Text:   name,
+			Title:  name,
+			Type:   "rss",
+			XMLURL: f.FeedUrl,
+		}
+		followTags := tags[f.ID]
+		if len(followTags) == 0 {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+		categories := make([]string, len(followTags))
+		for i, t := range followTags {
+			categories[i] = "/" + t
+		}
+		outline.Category = strings.Join(categories, ",")
+
+		i, ok := folders[followTags[0]]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			folders[followTags[0]] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{Text: followTags[0], Title: followTags[0]})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, outline)
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func importOPML(ctx context.Context, s *state, user database.User, r io.Reader) error {
+	var doc opmlDocument
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return fmt.Errorf("invalid OPML file: %w", err)
+	}
+
+	var created, followed, total int
+	var walk func(outlines []opmlOutline, folders []string) error
+	walk = func(outlines []opmlOutline, folders []string) error {
+		for _, o := range outlines {
+			name := o.Title
+			if name == "" {
+				name = o.Text
+			}
+			if o.XMLURL == "" {
+				err := walk(o.Outlines, append(folders, name))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				name = o.XMLURL
+			}
+
+			tagNames := append([]string{}, folders...)
+			for _, c := range strings.Split(o.Category, ",") {
+				if strings.TrimSpace(c) != "" {
+					tagNames = append(tagNames, c)
+				}
+			}
+
+			newFeed, newFollow, err := importOPMLFeed(ctx, s, user, name, o.XMLURL, tagNames)
+			if err != nil {
+				return fmt.Errorf("%s: %w", o.XMLURL, err)
+			}
+			total++
+			if newFeed {
+				created++
+			}
+			if newFollow {
+				followed++
+			}
+		}
+		return nil
+	}
+	err = walk(doc.Body.Outlines, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d feeds: %d new feeds added, %d new follows\n", total, created, followed)
+	return nil
+}
+
+// importOPMLFeed adds the feed if nobody has yet, follows it and attaches
+// the tags, all or nothing.
+func importOPMLFeed(ctx context.Context, s *state, user database.User, name, url string, tagNames []string) (newFeed, newFollow bool, err error) {
+	err = withTx(ctx, s, func(q *database.Queries) error {
+		feed, err := q.GetFeedByUrl(ctx, url)
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = q.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      name,
+				Url:       url,
+				UserID:    user.ID,
+			})
+			newFeed = true
+		}
+		if err != nil {
+			return fmt.Errorf("can't create feed: %w", err)
+		}
+
+		follow, err := q.GetFeedFollow(ctx, database.GetFeedFollowParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Keep the OPML title as the user's own title when the feed
+			// is already known under another name.
+			var title sql.NullString
+			if name != feed.Name {
+				title = nullString(name)
+			}
+			var row database.CreateFeedFollowRow
+			row, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				UserID:    user.ID,
+				FeedID:    feed.ID,
+				Title:     title,
+			})
+			follow = database.FeedFollow{ID: row.ID, UserID: row.UserID, FeedID: row.FeedID}
+			newFollow = true
+		}
+		if err != nil {
+			return fmt.Errorf("could not follow feed: %w", err)
+		}
+
+		for _, t := range tagNames {
+			err := addTag(ctx, q, user, follow, t)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return newFeed, newFollow, err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+var errWrongPassword = errors.New("wrong password")
+
+// stdin is shared by every prompt. A reader per prompt would buffer the
+// lines meant for the next one when input is piped.
+var stdin = bufio.NewReader(os.Stdin)
+
+func hashPassword(password string) (sql.NullString, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(hash), Valid: true}, nil
+}
+
+// checkPassword accepts any password for accounts that never set one, so
+// passwords stay optional.
+func checkPassword(hash sql.NullString, password string) error {
+	if !hash.Valid {
+		return nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(password))
+	if err != nil {
+		return errWrongPassword
+	}
+	return nil
+}
+
+// readPassword prompts on stderr and reads a password from stdin without
+// echoing it. When stdin is not a terminal it reads a single line so that
+// scripts can pipe the password in.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
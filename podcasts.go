@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// episodeDownload is one enclosure to save to disk.
+type episodeDownload struct {
+	PostID   uuid.UUID
+	FeedName string
+	Title    string
+	URL      string
+	MimeType string
+	Length   int64 // 0 when the feed doesn't say
+}
+
+var (
+	errQuotaExceeded   = errors.New("download directory is over its max_mb quota")
+	errDownloadStalled = errors.New("download stalled, run download again to resume")
+)
+
+func handlerEpisodes(s *state, cmd command, user database.User) error {
+	limit := cmd.intFlag("limit")
+	if limit < 1 {
+		return fmt.Errorf("usage: episodes [--feed <url>] [--limit <n>]: limit must be at least 1")
+	}
+	episodes, err := s.db.GetEpisodesForUser(context.Background(), database.GetEpisodesForUserParams{
+		UserID:  user.ID,
+		FeedUrl: nullString(cmd.stringFlag("feed")),
+		Limit:   int32(min(limit, math.MaxInt32)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get episodes: %w", err)
+	}
+	if len(episodes) == 0 {
+		fmt.Println("no episodes found.")
+		return nil
+	}
+	dir, err := s.cfg.Downloads.Directory()
+	if err != nil {
+		return fmt.Errorf("can't find the download directory: %w", err)
+	}
+
+	for _, ep := range episodes {
+		fmt.Printf("%s  %s\n", shortID(ep.PostID), ep.Title)
+		fmt.Printf("  Feed: %s, published %s\n", ep.FeedTitle, ep.PublishedAt.Format("2006-01-02"))
+		if ep.EpisodeNumber.Valid {
+			fmt.Printf("  Episode: %d\n", ep.EpisodeNumber.Int32)
+		}
+		if ep.EpisodeDuration.Valid {
+			fmt.Printf("  Duration: %s\n", formatDuration(ep.EpisodeDuration.Int32))
+		}
+		if ep.EpisodeImage.Valid {
+			fmt.Printf("  Image: %s\n", ep.EpisodeImage.String)
+		}
+		fmt.Printf("  File: %s\n", formatEnclosure(database.PostEnclosure{Url: ep.EnclosureUrl, MimeType: ep.MimeType, Length: ep.Length}))
+		if p := episodePath(dir, toEpisodeDownload(ep)); fileExists(p) {
+			fmt.Printf("  Downloaded: %s\n", p)
+		}
+	}
+	return nil
+}
+
+// handlerDownload saves the audio and video enclosures of one post. The
+// post is given by its URL or by the id prefix shown by episodes.
+func handlerDownload(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("usage: download <post>")
+	}
+	ctx := context.Background()
+	episodes, err := s.db.GetEpisodesForUser(ctx, database.GetEpisodesForUserParams{
+		UserID:       user.ID,
+		PostIDPrefix: nullString(escapeLike(strings.ToLower(cmd.args[0]))),
+		PostUrl:      nullString(cmd.args[0]),
+		Limit:        100,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get episodes: %w", err)
+	}
+	if len(episodes) == 0 {
+		return fmt.Errorf("no episode found for %s", cmd.args[0])
+	}
+	for _, ep := range episodes[1:] {
+		if ep.PostID != episodes[0].PostID {
+			return fmt.Errorf("post id %s is ambiguous", cmd.args[0])
+		}
+	}
+
+	for _, ep := range episodes {
+		path, fresh, err := downloadEpisode(ctx, s, toEpisodeDownload(ep))
+		if err != nil {
+			return fmt.Errorf("can't download %s: %w", ep.EnclosureUrl, err)
+		}
+		if !fresh {
+			fmt.Printf("Already downloaded: %s\n", path)
+			continue
+		}
+		fmt.Printf("Saved %s\n", path)
+	}
+	return nil
+}
+
+// feedAutoDownload turns on downloading the audio and video of new posts
+// while aggregating.
+func feedAutoDownload(s *state, feed database.Feed, args []string) error {
+	if len(args) < 1 || (args[0] != "on" && args[0] != "off") {
+		return fmt.Errorf("usage: feed auto-download <url> on|off")
+	}
+	on := args[0] == "on"
+	err := s.db.SetFeedAutoDownload(context.Background(), database.SetFeedAutoDownloadParams{
+		AutoDownload: on,
+		UpdatedAt:    time.Now(),
+		ID:           feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't change feed: %w", err)
+	}
+	if on {
+		fmt.Printf("New episodes of %q will be downloaded\n", feed.Name)
+		return nil
+	}
+	fmt.Printf("New episodes of %q will no longer be downloaded\n", feed.Name)
+	return nil
+}
+
+// autoDownloads feeds the background download worker, so that a slow or
+// stalled server never holds up collecting the other feeds. pending lets
+// a one-off scrape wait for the queue before the process exits.
+var (
+	autoDownloads      = make(chan episodeDownload, 256)
+	pendingDownloads   sync.WaitGroup
+	startDownloadsOnce sync.Once
+)
+
+// autoDownload queues the media enclosures of a newly stored post.
+func autoDownload(ctx context.Context, s *state, feed database.Feed, post database.Post) error {
+	enclosures, err := s.db.GetPostEnclosures(ctx, post.ID)
+	if err != nil {
+		return err
+	}
+	startDownloadsOnce.Do(func() { go downloadWorker(s) })
+	for _, e := range enclosures {
+		if !isMediaType(e.MimeType) {
+			continue
+		}
+		d := episodeDownload{
+			PostID:   post.ID,
+			FeedName: feed.Name,
+			Title:    post.Title,
+			URL:      e.Url,
+			MimeType: e.MimeType,
+			Length:   e.Length.Int64,
+		}
+		pendingDownloads.Add(1)
+		select {
+		case autoDownloads <- d:
+		default:
+			pendingDownloads.Done()
+			log.Printf("download queue is full, skipping %s (get it later with: gator download %s)", e.Url, shortID(post.ID))
+		}
+	}
+	return nil
+}
+
+func downloadWorker(s *state) {
+	for d := range autoDownloads {
+		_, _, err := downloadEpisode(context.Background(), s, d)
+		if err != nil {
+			log.Printf("failed to download episode %s: %v", d.URL, err)
+		}
+		pendingDownloads.Done()
+	}
+}
+
+// downloadEpisode saves an enclosure under the configured download
+// directory. Data goes to a .part file first; when one is left over from
+// an interrupted download, only the rest is requested with a Range header.
+// fresh is false when the file was already there.
+func downloadEpisode(ctx context.Context, s *state, d episodeDownload) (path string, fresh bool, err error) {
+	dir, err := s.cfg.Downloads.Directory()
+	if err != nil {
+		return "", false, err
+	}
+	path = episodePath(dir, d)
+	if fileExists(path) {
+		return path, false, nil
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", false, err
+	}
+
+	partPath := path + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	remaining := int64(-1)
+	if s.cfg.Downloads.MaxMB > 0 {
+		used, err := dirSize(dir)
+		if err != nil {
+			return "", false, err
+		}
+		remaining = s.cfg.Downloads.MaxMB<<20 - used
+		if remaining <= 0 || (d.Length > 0 && d.Length-offset > remaining) {
+			return "", false, errQuotaExceeded
+		}
+	}
+
+	// downloadClient has no overall timeout, so give up once the server
+	// stops sending for a while instead. Waiting for the headers is
+	// limited by the transport.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	req, err := newRequest(ctx, d.URL, "*/*")
+	if err != nil {
+		return "", false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := doRequest(downloadClient, req)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+	idle := time.AfterFunc(downloadIdleTimeout, func() { cancel(errDownloadStalled) })
+	defer idle.Stop()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0 &&
+		strings.HasPrefix(res.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		flags |= os.O_APPEND
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The .part file already holds the whole file.
+		return path, true, os.Rename(partPath, path)
+	case res.StatusCode == http.StatusOK:
+		// The server ignored the Range header; start over.
+		flags |= os.O_TRUNC
+		if remaining >= 0 {
+			remaining += offset
+		}
+		offset = 0
+	default:
+		return "", false, fmt.Errorf("response failed with status code: %d", res.StatusCode)
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return "", false, err
+	}
+	var body io.Reader = &idleReader{r: res.Body, timer: idle}
+	if remaining >= 0 {
+		body = io.LimitReader(body, remaining+1)
+	}
+	n, err := io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errDownloadStalled) {
+			err = cause
+		}
+		return "", false, err
+	}
+	if remaining >= 0 && n > remaining {
+		// Keep what fits so the download can resume once there is room.
+		err = os.Truncate(partPath, offset+remaining)
+		if err != nil {
+			return "", false, err
+		}
+		return "", false, errQuotaExceeded
+	}
+	return path, true, os.Rename(partPath, path)
+}
+
+// escapeLike makes LIKE match s literally, for Postgres' default \ escape.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// idleReader pushes its timer back every time data arrives.
+type idleReader struct {
+	r     io.Reader
+	timer *time.Timer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(downloadIdleTimeout)
+	}
+	return n, err
+}
+
+func toEpisodeDownload(ep database.GetEpisodesForUserRow) episodeDownload {
+	return episodeDownload{
+		PostID:   ep.PostID,
+		FeedName: ep.FeedName,
+		Title:    ep.Title,
+		URL:      ep.EnclosureUrl,
+		MimeType: ep.MimeType,
+		Length:   ep.Length.Int64,
+	}
+}
+
+// episodePath is <dir>/<feed name>/<title>-<post id prefix><ext>. The id prefix
+// keeps episodes with the same title apart.
+func episodePath(dir string, d episodeDownload) string {
+	ext := ""
+	if u, err := url.Parse(d.URL); err == nil {
+		ext = path.Ext(u.Path)
+	}
+	if len(ext) < 2 || len(ext) > 6 {
+		ext = ""
+		if exts, _ := mime.ExtensionsByType(d.MimeType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	name := fmt.Sprintf("%s-%s%s", safeFileName(d.Title), shortID(d.PostID), ext)
+	return filepath.Join(dir, safeFileName(d.FeedName), name)
+}
+
+// safeFileName turns a title into something every file system accepts.
+func safeFileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	name = strings.Trim(strings.Join(strings.Fields(name), " "), " .")
+	if runes := []rune(name); len(runes) > 80 {
+		name = strings.TrimSpace(string(runes[:80]))
+	}
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isMediaType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
+}
+
+// episodeFields reads the iTunes duration, episode number and image of an
+// item. Durations come as seconds, MM:SS or HH:MM:SS.
+func episodeFields(item RSSItem) (duration, number sql.NullInt32, image sql.NullString) {
+	if d, ok := parseEpisodeDuration(item.Duration); ok {
+		duration = sql.NullInt32{Int32: d, Valid: true}
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(item.Episode), 10, 32); err == nil && n > 0 {
+		number = sql.NullInt32{Int32: int32(n), Valid: true}
+	}
+	if href, ok := safeURL(item.Image.Href, nil, []string{"http", "https"}); ok {
+		image = nullString(href)
+	}
+	return duration, number, image
+}
+
+func parseEpisodeDuration(s string) (int32, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	var seconds int64
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseInt(part, 10, 32)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	if seconds <= 0 || seconds > 1<<31-1 {
+		return 0, false
+	}
+	return int32(seconds), true
+}
+
+func formatDuration(seconds int32) string {
+	h, m, sec := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
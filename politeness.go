@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHostInterval = time.Second
+	maxRetryAfter       = 7 * 24 * time.Hour
+	robotsTTL           = 24 * time.Hour
+	maxRobotsBytes      = 512 << 10
+)
+
+// limiter spaces out the requests of all fetches in the process. It is
+// replaced by configureHTTP once the config is read.
+var limiter = newRateLimiter(0, defaultHostInterval)
+
+// robots is nil unless http.robots is set in the config.
+var robots *robotsCache
+
+var errDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// rateLimiter lets at most one request start every global interval in
+// total and every host interval per host. A caller reserves its slot
+// before waiting, so concurrent fetches queue up in order.
+type rateLimiter struct {
+	mu      sync.Mutex
+	global  time.Duration
+	perHost time.Duration
+	next    time.Time
+	hosts   map[string]time.Time
+	delays  map[string]time.Duration // Crawl-delay from robots.txt
+}
+
+func newRateLimiter(perSecond float64, perHost time.Duration) *rateLimiter {
+	l := &rateLimiter{
+		perHost: perHost,
+		hosts:   make(map[string]time.Time),
+		delays:  make(map[string]time.Duration),
+	}
+	if perSecond > 0 {
+		l.global = time.Duration(float64(time.Second) / perSecond)
+	}
+	return l
+}
+
+func (l *rateLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	at := time.Now()
+	if l.next.After(at) {
+		at = l.next
+	}
+	if t := l.hosts[host]; t.After(at) {
+		at = t
+	}
+	l.next = at.Add(l.global)
+	interval := l.perHost
+	if d := l.delays[host]; d > interval {
+		interval = d
+	}
+	l.hosts[host] = at.Add(interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) setCrawlDelay(host string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delays[host] = d
+}
+
+// doRequest sends a request once robots.txt allows it and the host's turn
+// has come.
+func doRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	if robots != nil {
+		allowed, err := robots.allowed(req.Context(), req.URL)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errDisallowedByRobots
+		}
+	}
+	err := limiter.wait(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// retryAfterError is returned for a 429 or 503 response that says when to
+// come back.
+type retryAfterError struct {
+	status int
+	until  time.Time
+}
+
+func (e *retryAfterError) Error() string {
+	return fmt.Sprintf("response failed with status code: %d, retry after %s", e.status, e.until.Format("2006-01-02 15:04"))
+}
+
+// parseRetryAfter reads a Retry-After header, which holds either seconds
+// or an HTTP date. Very long waits are capped so a feed isn't lost for good.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	var d time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return time.Time{}, false
+		}
+		d = time.Duration(min(seconds, int64(maxRetryAfter/time.Second))) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(now)
+	} else {
+		return time.Time{}, false
+	}
+	if d <= 0 {
+		return time.Time{}, false
+	}
+	return now.Add(min(d, maxRetryAfter)), true
+}
+
+// robotsCache keeps the parsed robots.txt of each host for a day.
+type robotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsRules
+}
+
+type robotsRules struct {
+	fetched    time.Time
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{hosts: make(map[string]*robotsRules)}
+}
+
+func (c *robotsCache) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return true, nil
+	}
+	key := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	rules := c.hosts[key]
+	c.mu.Unlock()
+	if rules == nil || time.Since(rules.fetched) > robotsTTL {
+		var err error
+		rules, err = fetchRobots(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		c.mu.Lock()
+		c.hosts[key] = rules
+		c.mu.Unlock()
+		if rules.crawlDelay > 0 {
+			limiter.setCrawlDelay(u.Host, rules.crawlDelay)
+		}
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allows(path), nil
+}
+
+// fetchRobots downloads robots.txt for a site. Only a successful response
+// restricts anything: a missing file or a broken server allows everything,
+// and the site is asked again after robotsTTL.
+func fetchRobots(ctx context.Context, site string) (*robotsRules, error) {
+	rules := &robotsRules{fetched: time.Now()}
+	req, err := newRequest(ctx, site+"/robots.txt", "text/plain")
+	if err != nil {
+		return rules, nil
+	}
+	err = limiter.wait(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return rules, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return rules, nil
+	}
+	parseRobots(io.LimitReader(res.Body, maxRobotsBytes), rules)
+	return rules, nil
+}
+
+// parseRobots reads the groups for gator, or for * when no group names
+// gator, as described in RFC 9309. Crawl-delay isn't in the RFC but many
+// sites use it.
+func parseRobots(r io.Reader, rules *robotsRules) {
+	type group struct {
+		agents     []string
+		rules      []robotsRule
+		crawlDelay time.Duration
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "user-agent" {
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			continue
+		}
+		inAgents = false
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "allow", "disallow":
+			if value == "" {
+				continue
+			}
+			if rule, ok := newRobotsRule(key == "allow", value); ok {
+				current.rules = append(current.rules, rule)
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(min(seconds, 60) * float64(time.Second))
+			}
+		}
+	}
+
+	for _, agent := range []string{"gator", "*"} {
+		found := false
+		for _, g := range groups {
+			if !slices.Contains(g.agents, agent) {
+				continue
+			}
+			found = true
+			rules.rules = append(rules.rules, g.rules...)
+			rules.crawlDelay = max(rules.crawlDelay, g.crawlDelay)
+		}
+		if found {
+			return
+		}
+	}
+}
+
+// newRobotsRule turns a path pattern into a regexp: * matches anything and
+// a trailing $ anchors the end.
+func newRobotsRule(allow bool, pattern string) (robotsRule, bool) {
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(expr, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr = "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return robotsRule{}, false
+	}
+	return robotsRule{allow: allow, length: len(pattern), pattern: re}, true
+}
+
+// allows applies the longest matching rule; Allow wins a tie.
+func (r *robotsRules) allows(path string) bool {
+	best := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best = rule.length
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
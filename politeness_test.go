@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	tests := []struct {
+		name       string
+		robots     string
+		allowed    []string
+		disallowed []string
+		crawlDelay time.Duration
+	}{
+		{
+			name:    "empty file",
+			robots:  "",
+			allowed: []string{"/", "/feed.xml"},
+		},
+		{
+			name:       "star group",
+			robots:     "User-agent: *\nDisallow: /private\n",
+			allowed:    []string{"/", "/public"},
+			disallowed: []string{"/private", "/private/feed.xml"},
+		},
+		{
+			name: "gator group wins over star",
+			robots: "User-agent: *\nDisallow: /\n\n" +
+				"User-agent: gator\nDisallow: /admin\n",
+			allowed:    []string{"/", "/feed.xml"},
+			disallowed: []string{"/admin"},
+		},
+		{
+			name:    "agent matching ignores case",
+			robots:  "User-agent: *\nDisallow: /\n\nUser-agent: Gator\nAllow: /\n",
+			allowed: []string{"/feed.xml"},
+		},
+		{
+			name:    "group for another crawler is ignored",
+			robots:  "User-agent: googlebot\nDisallow: /\n",
+			allowed: []string{"/", "/feed.xml"},
+		},
+		{
+			name: "several agents share a group",
+			robots: "User-agent: googlebot\nUser-agent: gator\nDisallow: /shared\n\n" +
+				"User-agent: *\nDisallow: /\n",
+			allowed:    []string{"/feed.xml"},
+			disallowed: []string{"/shared"},
+		},
+		{
+			name: "groups for the same agent are merged",
+			robots: "User-agent: gator\nDisallow: /a\n\n" +
+				"User-agent: other\nDisallow: /b\n\n" +
+				"User-agent: gator\nDisallow: /c\n",
+			allowed:    []string{"/b"},
+			disallowed: []string{"/a", "/c"},
+		},
+		{
+			name:       "longest match wins",
+			robots:     "User-agent: *\nDisallow: /blog\nAllow: /blog/feed\n",
+			allowed:    []string{"/blog/feed", "/blog/feed.xml"},
+			disallowed: []string{"/blog", "/blog/post"},
+		},
+		{
+			name:    "allow wins a tie",
+			robots:  "User-agent: *\nDisallow: /feed\nAllow: /feed\n",
+			allowed: []string{"/feed"},
+		},
+		{
+			name:       "wildcard and end anchor",
+			robots:     "User-agent: *\nDisallow: /*.php$\nDisallow: /*?session=\n",
+			allowed:    []string{"/index.php5", "/feed?page=2"},
+			disallowed: []string{"/index.php", "/a/b.php", "/feed?session=1"},
+		},
+		{
+			name:    "empty disallow allows everything",
+			robots:  "User-agent: *\nDisallow:\n",
+			allowed: []string{"/"},
+		},
+		{
+			name:       "comments and odd spacing",
+			robots:     "# robots\nUSER-AGENT : *   # everyone\n  disallow:  /tmp  # scratch\n",
+			allowed:    []string{"/"},
+			disallowed: []string{"/tmp/x"},
+		},
+		{
+			name:       "rules before any user-agent are ignored",
+			robots:     "Disallow: /\nUser-agent: *\nDisallow: /x\n",
+			allowed:    []string{"/"},
+			disallowed: []string{"/x"},
+		},
+		{
+			name:       "crawl delay",
+			robots:     "User-agent: gator\nCrawl-delay: 2.5\n",
+			allowed:    []string{"/"},
+			crawlDelay: 2500 * time.Millisecond,
+		},
+		{
+			name:       "crawl delay is capped",
+			robots:     "User-agent: *\nCrawl-delay: 3600\n",
+			allowed:    []string{"/"},
+			crawlDelay: time.Minute,
+		},
+		{
+			name:    "crawl delay of another group",
+			robots:  "User-agent: *\nCrawl-delay: 10\n\nUser-agent: gator\nAllow: /\n",
+			allowed: []string{"/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &robotsRules{}
+			parseRobots(strings.NewReader(tt.robots), rules)
+			for _, path := range tt.allowed {
+				if !rules.allows(path) {
+					t.Errorf("allows(%q) = false, want true", path)
+				}
+			}
+			for _, path := range tt.disallowed {
+				if rules.allows(path) {
+					t.Errorf("allows(%q) = true, want false", path)
+				}
+			}
+			if rules.crawlDelay != tt.crawlDelay {
+				t.Errorf("crawlDelay = %v, want %v", rules.crawlDelay, tt.crawlDelay)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "seconds", value: "120", want: now.Add(2 * time.Minute), wantOK: true},
+		{name: "seconds with spaces", value: " 30 ", want: now.Add(30 * time.Second), wantOK: true},
+		{name: "seconds are capped", value: "99999999999", want: now.Add(maxRetryAfter), wantOK: true},
+		{name: "http date", value: now.Add(time.Hour).Format(http.TimeFormat), want: now.Add(time.Hour), wantOK: true},
+		{name: "rfc 850 date", value: "Friday, 01-Mar-24 13:30:00 GMT", want: now.Add(90 * time.Minute), wantOK: true},
+		{name: "date is capped", value: now.AddDate(1, 0, 0).Format(http.TimeFormat), want: now.Add(maxRetryAfter), wantOK: true},
+		{name: "date in the past", value: now.Add(-time.Hour).Format(http.TimeFormat)},
+		{name: "zero", value: "0"},
+		{name: "negative", value: "-5"},
+		{name: "empty", value: ""},
+		{name: "garbage", value: "soon"},
+		{name: "fraction", value: "1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// postsPageSize is how many rows listPosts reads per query while filters
+// are applied, independent of how many posts it returns.
+const postsPageSize = 100
+
+// userPost is a post as a user sees it: one per cluster of duplicates,
+// with the feeds of the other visible copies in AlsoIn.
+type userPost struct {
+	database.GetPostsForUserRow
+	AlsoIn string
+}
+
+// postCluster is what listPosts learned about a cluster of duplicates.
+type postCluster struct {
+	representative uuid.UUID
+	alsoIn         string
+}
+
+// listPosts is how browse, the web UI and the API list a user's posts.
+// Posts the user's filters hide are dropped first; each remaining cluster
+// of duplicates is then shown once, as its earliest visible copy. offset
+// and limit count the posts returned, not rows read.
+func listPosts(ctx context.Context, s *state, user database.User, params database.GetPostsForUserParams, offset, limit int) ([]userPost, error) {
+	rules, err := userFilters(ctx, s, user)
+	if err != nil {
+		return nil, err
+	}
+	visible := func(p database.GetPostsForUserRow) bool {
+		return !evaluateFilters(rules, filterPost{
+			FeedID:      p.FeedID,
+			Title:       p.Title,
+			Description: p.Description,
+			URL:         p.Url,
+			Author:      p.Author.String,
+			Categories:  p.Categories,
+		}).hide
+	}
+
+	params.UserID = user.ID
+	clusters := make(map[uuid.UUID]postCluster)
+	var posts []userPost
+	skipped := 0
+	for rowOffset := 0; len(posts) < limit; {
+		params.ClusterIds = nil
+		params.Limit = postsPageSize
+		params.Offset = int32(rowOffset)
+		page, err := s.db.GetPostsForUser(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get posts: %w", err)
+		}
+		err = loadClusters(ctx, s, params, page, clusters, visible)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			if !visible(p) {
+				continue
+			}
+			post := userPost{GetPostsForUserRow: p}
+			if p.ClusterID.Valid {
+				c := clusters[p.ClusterID.UUID]
+				if c.representative != p.ID {
+					continue
+				}
+				post.AlsoIn = c.alsoIn
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			posts = append(posts, post)
+			if len(posts) == limit {
+				break
+			}
+		}
+		if len(page) < postsPageSize {
+			break
+		}
+		rowOffset += len(page)
+	}
+	return posts, nil
+}
+
+// loadClusters reads every copy of the clusters on page that aren't known
+// yet, under the same conditions as the page itself, and records each
+// cluster's earliest visible copy and the feeds of its other visible copies.
+func loadClusters(ctx context.Context, s *state, params database.GetPostsForUserParams, page []database.GetPostsForUserRow, clusters map[uuid.UUID]postCluster, visible func(database.GetPostsForUserRow) bool) error {
+	var ids []uuid.UUID
+	for _, p := range page {
+		if !p.ClusterID.Valid {
+			continue
+		}
+		if _, ok := clusters[p.ClusterID.UUID]; ok || slices.Contains(ids, p.ClusterID.UUID) {
+			continue
+		}
+		ids = append(ids, p.ClusterID.UUID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	params.ClusterIds = ids
+	params.Limit = math.MaxInt32
+	params.Offset = 0
+	copies, err := s.db.GetPostsForUser(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to get duplicate posts: %w", err)
+	}
+	byCluster := make(map[uuid.UUID][]database.GetPostsForUserRow)
+	for _, c := range copies {
+		if visible(c) {
+			byCluster[c.ClusterID.UUID] = append(byCluster[c.ClusterID.UUID], c)
+		}
+	}
+	for _, id := range ids {
+		// Copies come newest first, so the last one is the earliest.
+		visibleCopies := byCluster[id]
+		if len(visibleCopies) == 0 {
+			clusters[id] = postCluster{}
+			continue
+		}
+		first := visibleCopies[len(visibleCopies)-1]
+		var feeds []string
+		for _, c := range visibleCopies {
+			if c.FeedID != first.FeedID && !slices.Contains(feeds, c.FeedTitle) {
+				feeds = append(feeds, c.FeedTitle)
+			}
+		}
+		slices.Sort(feeds)
+		clusters[id] = postCluster{representative: first.ID, alsoIn: strings.Join(feeds, ", ")}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+)
+
+const profileUsage = `usage:
+  profile list
+  profile use <name>
+  profile add <name> <db_url>`
+
+func handlerProfile(s *state, cmd command) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf(profileUsage)
+	}
+	switch cmd.args[0] {
+	case "list":
+		for _, name := range s.cfg.ProfileNames() {
+			p, _ := s.cfg.GetProfile(name)
+			marker := " "
+			if name == s.cfg.Profile() {
+				marker = "*"
+			}
+			user := p.CurrentUserName
+			if user == "" {
+				user = "-"
+			}
+			line := fmt.Sprintf("%s %s (user: %s)", marker, name, user)
+			if name == s.cfg.ActiveProfile() {
+				line += " [active]"
+			}
+			fmt.Println(line)
+		}
+		return nil
+	case "use":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("usage: profile use <name>")
+		}
+		err := s.cfg.SetActiveProfile(cmd.args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("active profile set to: %s\n", cmd.args[1])
+		return nil
+	case "add":
+		if len(cmd.args) < 3 {
+			return fmt.Errorf("usage: profile add <name> <db_url>")
+		}
+		err := s.cfg.AddProfile(cmd.args[1], cmd.args[2])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("profile %s added; switch to it with: gator profile use %s\n", cmd.args[1], cmd.args[1])
+		return nil
+	default:
+		return fmt.Errorf(profileUsage)
+	}
+}
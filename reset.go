@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+const resetUsage = "usage: reset --posts|--follows|--user <name>|--all [--yes] [--backup <file>]"
+
+type resetPlan struct {
+	counts []string
+	run    func(ctx context.Context) error
+}
+
+type backupSnapshot struct {
+	CreatedAt       time.Time
+	Users           []database.User
+	Feeds           []database.Feed
+	FeedCredentials []database.FeedCredential
+	FeedFollows     []database.FeedFollow
+	FeedHeaders     []database.FeedHeader
+	Posts           []database.Post
+	PostEnclosures  []database.PostEnclosure
+	PostStates      []database.PostState
+	Tags            []database.Tag
+	FeedFollowTags  []database.FeedFollowTag
+	Filters         []database.Filter
+}
+
+func handlerReset(s *state, cmd command, user database.User) error {
+	posts := cmd.boolFlag("posts")
+	follows := cmd.boolFlag("follows")
+	userName := cmd.stringFlag("user")
+	all := cmd.boolFlag("all")
+	backup := cmd.stringFlag("backup")
+
+	scopes := 0
+	for _, set := range []bool{posts, follows, userName != "", all} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes != 1 {
+		return fmt.Errorf(resetUsage)
+	}
+
+	ctx := context.Background()
+	var plan resetPlan
+	var err error
+	switch {
+	case posts:
+		plan, err = planResetPosts(ctx, s)
+	case follows:
+		plan, err = planResetFollows(ctx, s)
+	case userName != "":
+		plan, err = planResetUser(ctx, s, user, userName)
+	case all:
+		plan, err = planResetAll(ctx, s)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("This will delete:")
+	for _, line := range plan.counts {
+		fmt.Printf("  %s\n", line)
+	}
+
+	if !cmd.boolFlag("yes") {
+		ok, err := confirm(`Type "yes" to continue: `)
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !ok {
+			fmt.Println("reset cancelled")
+			return nil
+		}
+	}
+
+	if backup != "" {
+		err := writeBackup(ctx, s, backup)
+		if err != nil {
+			return fmt.Errorf("failed to write backup, nothing was deleted: %w", err)
+		}
+		fmt.Printf("backup written to %s\n", backup)
+	}
+
+	err = plan.run(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reset database %w", err)
+	}
+	fmt.Println("reset complete")
+	return nil
+}
+
+func planResetPosts(ctx context.Context, s *state) (resetPlan, error) {
+	counts, err := s.db.GetTableCounts(ctx)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return resetPlan{
+		counts: []string{
+			fmt.Sprintf("%d posts", counts.Posts),
+			fmt.Sprintf("%d read/starred marks", counts.PostStates),
+		},
+		run: func(ctx context.Context) error {
+			_, err := s.db.DeletePosts(ctx)
+			return err
+		},
+	}, nil
+}
+
+func planResetFollows(ctx context.Context, s *state) (resetPlan, error) {
+	counts, err := s.db.GetTableCounts(ctx)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return resetPlan{
+		counts: []string{fmt.Sprintf("%d feed follows", counts.FeedFollows)},
+		run: func(ctx context.Context) error {
+			_, err := s.db.DeleteFeedFollows(ctx)
+			return err
+		},
+	}, nil
+}
+
+func planResetUser(ctx context.Context, s *state, current database.User, name string) (resetPlan, error) {
+	target, err := s.db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return resetPlan{}, fmt.Errorf("user does not exist")
+	}
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if target.ID == current.ID {
+		return resetPlan{}, fmt.Errorf("you can't delete yourself; use --all or another admin account")
+	}
+
+	counts, err := s.db.GetUserDeletionCounts(ctx, target.ID)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return resetPlan{
+		counts: []string{
+			fmt.Sprintf("user %s", target.Name),
+			fmt.Sprintf("%d feed follows of %s", counts.FeedFollows, target.Name),
+			fmt.Sprintf("(%d feeds created by %s are kept and handed over to %s)", counts.Feeds, target.Name, current.Name),
+		},
+		run: func(ctx context.Context) error {
+			transferred, err := deleteUserKeepingFeeds(ctx, s, target, current)
+			if err == nil && transferred > 0 {
+				fmt.Printf("%d feeds created by %s now belong to %s\n", transferred, target.Name, current.Name)
+			}
+			return err
+		},
+	}, nil
+}
+
+// deleteUserKeepingFeeds hands the user's feeds over to heir before deleting
+// the user, so followers keep their feeds and posts. The feeds.user_id
+// foreign key is ON DELETE RESTRICT, so skipping the transfer fails loudly.
+func deleteUserKeepingFeeds(ctx context.Context, s *state, user, heir database.User) (int64, error) {
+	var transferred int64
+	err := withTx(ctx, s, func(q *database.Queries) error {
+		var err error
+		transferred, err = q.TransferFeedsOwnedBy(ctx, database.TransferFeedsOwnedByParams{
+			NewOwnerID: heir.ID,
+			UpdatedAt:  time.Now(),
+			OldOwnerID: user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to transfer feeds: %w", err)
+		}
+		return q.DeleteUser(ctx, user.ID)
+	})
+	return transferred, err
+}
+
+func planResetAll(ctx context.Context, s *state) (resetPlan, error) {
+	counts, err := s.db.GetTableCounts(ctx)
+	if err != nil {
+		return resetPlan{}, fmt.Errorf("failed to count rows: %w", err)
+	}
+	return resetPlan{
+		counts: []string{
+			fmt.Sprintf("%d users", counts.Users),
+			fmt.Sprintf("%d feeds", counts.Feeds),
+			fmt.Sprintf("%d feed follows", counts.FeedFollows),
+			fmt.Sprintf("%d posts", counts.Posts),
+			fmt.Sprintf("%d read/starred marks", counts.PostStates),
+		},
+		run: func(ctx context.Context) error {
+			err := withTx(ctx, s, func(q *database.Queries) error {
+				err := q.DeleteFeeds(ctx)
+				if err != nil {
+					return err
+				}
+				return q.ResetUsers(ctx)
+			})
+			if err != nil {
+				return err
+			}
+			// The config's user is gone too.
+			err = s.cfg.SetUser("", "")
+			if err != nil {
+				return fmt.Errorf("failed to clear current user: %w", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func confirm(prompt string) (bool, error) {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return false, err
+	}
+	return strings.TrimSpace(line) == "yes", nil
+}
+
+// writeBackup dumps every table, not only the rows in scope, so that a
+// snapshot taken before any reset is always complete. The file contains
+// password and API key hashes, hence 0600.
+func writeBackup(ctx context.Context, s *state, path string) error {
+	var snap backupSnapshot
+	var err error
+	snap.CreatedAt = time.Now()
+	if snap.Users, err = s.db.GetUsers(ctx); err != nil {
+		return err
+	}
+	if snap.Feeds, err = s.db.GetAllFeeds(ctx); err != nil {
+		return err
+	}
+	if snap.FeedFollows, err = s.db.GetAllFeedFollows(ctx); err != nil {
+		return err
+	}
+	if snap.FeedCredentials, err = s.db.GetAllFeedCredentials(ctx); err != nil {
+		return err
+	}
+	if snap.FeedHeaders, err = s.db.GetAllFeedHeaders(ctx); err != nil {
+		return err
+	}
+	if snap.Posts, err = s.db.GetAllPosts(ctx); err != nil {
+		return err
+	}
+	if snap.PostEnclosures, err = s.db.GetAllPostEnclosures(ctx); err != nil {
+		return err
+	}
+	if snap.PostStates, err = s.db.GetAllPostStates(ctx); err != nil {
+		return err
+	}
+	if snap.Tags, err = s.db.GetAllTags(ctx); err != nil {
+		return err
+	}
+	if snap.FeedFollowTags, err = s.db.GetAllFeedFollowTags(ctx); err != nil {
+		return err
+	}
+	if snap.Filters, err = s.db.GetAllFilters(ctx); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0600)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements lists the elements kept by sanitizeHTML and the
+// attributes each of them may keep. Other elements are unwrapped: their
+// children stay, the element itself goes.
+var allowedElements = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.P:          nil,
+	atom.Br:         nil,
+	atom.Hr:         nil,
+	atom.B:          nil,
+	atom.Strong:     nil,
+	atom.I:          nil,
+	atom.Em:         nil,
+	atom.U:          nil,
+	atom.S:          nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Small:      nil,
+	atom.Mark:       nil,
+	atom.Code:       nil,
+	atom.Pre:        nil,
+	atom.Blockquote: {"cite"},
+	atom.Q:          {"cite"},
+	atom.Cite:       nil,
+	atom.Abbr:       {"title"},
+	atom.Ul:         nil,
+	atom.Ol:         nil,
+	atom.Li:         nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Dd:         nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Table:      nil,
+	atom.Thead:      nil,
+	atom.Tbody:      nil,
+	atom.Tfoot:      nil,
+	atom.Tr:         nil,
+	atom.Th:         nil,
+	atom.Td:         nil,
+	atom.Caption:    nil,
+	atom.Figure:     nil,
+	atom.Figcaption: nil,
+	atom.Div:        nil,
+	atom.Span:       nil,
+}
+
+// droppedElements are removed together with everything inside them.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Noscript: true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Template: true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Base:     true,
+	atom.Title:    true,
+	atom.Head:     true,
+}
+
+// URL attributes and the schemes they may use once resolved.
+var urlAttributes = map[string][]string{
+	"href": {"http", "https", "mailto"},
+	"src":  {"http", "https"},
+	"cite": {"http", "https"},
+}
+
+// sanitizeHTML turns untrusted markup from a feed or a web page into a
+// safe HTML fragment and a plain-text rendering of it. Relative URLs are
+// resolved against base, which is normally the item's link.
+func sanitizeHTML(fragment, base string) (safeHTML, text string) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		baseURL = nil
+	}
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), root)
+	if err != nil {
+		return "", strings.Join(strings.Fields(fragment), " ")
+	}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	cleanChildren(root, baseURL)
+
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		// Rendering a tree we built ourselves only fails on write errors,
+		// which a bytes.Buffer doesn't have.
+		_ = html.Render(&buf, c)
+	}
+	return strings.TrimSpace(buf.String()), blockText(root)
+}
+
+func cleanChildren(n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			cleanElement(n, c, base)
+		default:
+			n.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+func cleanElement(parent, n *html.Node, base *url.URL) {
+	if droppedElements[n.DataAtom] {
+		parent.RemoveChild(n)
+		return
+	}
+	cleanChildren(n, base)
+
+	allowed, ok := allowedElements[n.DataAtom]
+	if !ok || n.DataAtom == 0 {
+		// Unknown element: keep what it contains.
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			n.RemoveChild(c)
+			parent.InsertBefore(c, n)
+			c = next
+		}
+		parent.RemoveChild(n)
+		return
+	}
+
+	var attrs []html.Attribute
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(allowed, a.Key) {
+			continue
+		}
+		if schemes, isURL := urlAttributes[a.Key]; isURL {
+			resolved, ok := safeURL(a.Val, base, schemes)
+			if !ok {
+				continue
+			}
+			a.Val = resolved
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+
+	switch n.DataAtom {
+	case atom.Img:
+		if attr(n, "src") == "" || isTrackingPixel(n) {
+			parent.RemoveChild(n)
+		}
+	case atom.A:
+		if attr(n, "href") != "" {
+			n.Attr = append(n.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+		}
+	}
+}
+
+// safeURL resolves raw against base and accepts it only with one of the
+// given schemes, which rules out javascript: and data: URLs.
+func safeURL(raw string, base *url.URL, schemes []string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isTrackingPixel catches the 1x1 images feeds embed to count readers.
+func isTrackingPixel(n *html.Node) bool {
+	w, h := attr(n, "width"), attr(n, "height")
+	return w == "0" || w == "1" || h == "0" || h == "1"
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// postDescription returns the stored safe-HTML and plain-text variants of
+// a post's description, sanitizing on the fly for posts stored before the
+// variants existed.
+func postDescription(raw string, safeHTML, text sql.NullString, link string) (string, string) {
+	if safeHTML.Valid || text.Valid {
+		return safeHTML.String, text.String
+	}
+	return sanitizeHTML(raw, link)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		base     string
+		wantHTML string
+		wantText string
+	}{
+		{
+			name:     "plain link",
+			fragment: `<a href="https://example.com/a" onclick="alert(1)">read</a>`,
+			wantHTML: `<a href="https://example.com/a" rel="nofollow noopener noreferrer">read</a>`,
+			wantText: "read",
+		},
+		{
+			name:     "relative link",
+			fragment: `<a href="/post/1">post</a>`,
+			base:     "https://example.com/feed/",
+			wantHTML: `<a href="https://example.com/post/1" rel="nofollow noopener noreferrer">post</a>`,
+			wantText: "post",
+		},
+		{
+			name:     "javascript href",
+			fragment: `<a href="javascript:alert(1)">x</a>`,
+			wantHTML: `<a>x</a>`,
+			wantText: "x",
+		},
+		{
+			name:     "javascript href with whitespace and case",
+			fragment: `<a href=" JaVaScRiPt:alert(1)">x</a>`,
+			base:     "https://example.com/",
+			wantHTML: `<a>x</a>`,
+			wantText: "x",
+		},
+		{
+			name:     "entity encoded javascript href",
+			fragment: `<a href="&#106;avascript:alert(1)">x</a>`,
+			wantHTML: `<a>x</a>`,
+			wantText: "x",
+		},
+		{
+			name:     "data href",
+			fragment: `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+			wantHTML: `<a>x</a>`,
+			wantText: "x",
+		},
+		{
+			name:     "data image",
+			fragment: `<img src="data:image/svg+xml,<svg onload=alert(1)>">`,
+		},
+		{
+			name:     "script",
+			fragment: `<p>hi<script>alert(1)</script></p>`,
+			wantHTML: `<p>hi</p>`,
+			wantText: "hi",
+		},
+		{
+			name:     "noscript mXSS",
+			fragment: `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`,
+			wantHTML: `&#34;&gt;`,
+			wantText: `">`,
+		},
+		{
+			name:     "svg style mXSS",
+			fragment: `<svg></p><style><a id="</style><img src=1 onerror=alert(1)>">`,
+		},
+		{
+			name:     "math mXSS",
+			fragment: `<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+		},
+		{
+			name:     "comment",
+			fragment: `a<!-- <img src=x onerror=alert(1)> -->b`,
+			wantHTML: `ab`,
+			wantText: "ab",
+		},
+		{
+			name:     "unknown element is unwrapped",
+			fragment: `<custom-tag onmouseover="alert(1)">text</custom-tag>`,
+			wantHTML: `text`,
+			wantText: "text",
+		},
+		{
+			name:     "tracking pixel",
+			fragment: `<p>story</p><img src="https://tracker.example/p.gif" width="1" height="1">`,
+			wantHTML: `<p>story</p>`,
+			wantText: "story",
+		},
+		{
+			name:     "zero height pixel",
+			fragment: `<img src="https://tracker.example/p.gif" height="0">`,
+		},
+		{
+			name:     "image is kept",
+			fragment: `<img src="https://example.com/a.png" alt="a" width="640" onerror="alert(1)">`,
+			wantHTML: `<img src="https://example.com/a.png" alt="a" width="640"/>`,
+		},
+		{
+			name:     "image without src",
+			fragment: `<img alt="a" src="javascript:alert(1)">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHTML, gotText := sanitizeHTML(tt.fragment, tt.base)
+			if gotHTML != tt.wantHTML {
+				t.Errorf("html = %q, want %q", gotHTML, tt.wantHTML)
+			}
+			if gotText != tt.wantText {
+				t.Errorf("text = %q, want %q", gotText, tt.wantText)
+			}
+			lower := strings.ToLower(gotHTML)
+			for _, bad := range []string{"<script", "onerror", "onclick", "javascript:", "data:"} {
+				if strings.Contains(lower, bad) {
+					t.Errorf("html %q contains %q", gotHTML, bad)
+				}
+			}
+		})
+	}
+}
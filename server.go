@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+const (
+	sessionCookieName = "gator_session"
+	postsPerPage      = 20
+)
+
+type webServer struct {
+	st   *state
+	tmpl *template.Template
+
+	mu       sync.Mutex
+	sessions map[string]uuid.UUID
+}
+
+type indexPage struct {
+	User     database.User
+	Posts    []userPost
+	Follows  []database.GetFeedFollowsForUserRow
+	Page     int
+	PrevPage int
+	NextPage int
+	HasNext  bool
+	Error    string
+}
+
+type loginPage struct {
+	Error string
+}
+
+func handlerServe(s *state, cmd command) error {
+	addr := cmd.stringFlag("addr")
+	agg := cmd.durationFlag("agg")
+
+	tmpl, err := template.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	ws := &webServer{
+		st:       s,
+		tmpl:     tmpl,
+		sessions: make(map[string]uuid.UUID),
+	}
+
+	if agg > 0 {
+		fmt.Printf("Collecting feeds every %s\n", agg)
+		go runAgg(context.Background(), s, agg)
+	}
+
+	fmt.Printf("Listening on %s\n", addr)
+	return http.ListenAndServe(addr, ws.routes())
+}
+
+func (ws *webServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login", ws.handleLoginForm)
+	mux.HandleFunc("POST /login", ws.handleLogin)
+	mux.HandleFunc("POST /logout", ws.handleLogout)
+	mux.HandleFunc("GET /{$}", ws.withUser(ws.handleIndex))
+	mux.HandleFunc("POST /posts/{id}/read", ws.withUser(ws.handleMarkRead))
+	mux.HandleFunc("POST /posts/{id}/star", ws.withUser(ws.handleStar))
+	mux.HandleFunc("POST /feeds", ws.withUser(ws.handleAddFeed))
+	mux.HandleFunc("POST /follows", ws.withUser(ws.handleFollow))
+	registerAPIRoutes(mux, ws.st)
+	return mux
+}
+
+// withUser is the web counterpart of middlewareLoggedIn: it resolves the
+// session cookie to a user and redirects to the login form otherwise.
+func (ws *webServer) withUser(handler func(w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		ws.mu.Lock()
+		userID, ok := ws.sessions[cookie.Value]
+		ws.mu.Unlock()
+		if !ok {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		user, err := ws.st.db.GetUserById(r.Context(), userID)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+func (ws *webServer) render(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := ws.tmpl.ExecuteTemplate(w, name, data)
+	if err != nil {
+		log.Printf("failed to render %s: %v", name, err)
+	}
+}
+
+func (ws *webServer) handleLoginForm(w http.ResponseWriter, r *http.Request) {
+	ws.render(w, "login", loginPage{})
+}
+
+func (ws *webServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	user, err := ws.st.db.GetUser(r.Context(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusUnauthorized)
+		ws.render(w, "login", loginPage{Error: "user does not exist"})
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to get user", http.StatusInternalServerError)
+		return
+	}
+	// checkPassword lets passwordless accounts in, which is fine for the
+	// CLI's own user but would let anyone who can reach the server in.
+	if !user.PasswordHash.Valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		ws.render(w, "login", loginPage{Error: "this account has no password; set one with gator passwd"})
+		return
+	}
+	if err := checkPassword(user.PasswordHash, r.FormValue("password")); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		ws.render(w, "login", loginPage{Error: err.Error()})
+		return
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		http.Error(w, "failed to create session", http.StatusInternalServerError)
+		return
+	}
+	ws.mu.Lock()
+	ws.sessions[token] = user.ID
+	ws.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (ws *webServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		ws.mu.Lock()
+		delete(ws.sessions, cookie.Value)
+		ws.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   sessionCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (ws *webServer) handleIndex(w http.ResponseWriter, r *http.Request, user database.User) {
+	page := 1
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	// Ask for one extra row to find out whether there is a next page.
+	posts, err := listPosts(r.Context(), ws.st, user, database.GetPostsForUserParams{
+		UnreadOnly: true,
+	}, (page-1)*postsPerPage, postsPerPage+1)
+	if err != nil {
+		http.Error(w, "failed to get posts", http.StatusInternalServerError)
+		return
+	}
+	hasNext := len(posts) > postsPerPage
+	if hasNext {
+		posts = posts[:postsPerPage]
+	}
+
+	follows, err := ws.st.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "failed to get subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	ws.render(w, "index", indexPage{
+		User:     user,
+		Posts:    posts,
+		Follows:  follows,
+		Page:     page,
+		PrevPage: page - 1,
+		NextPage: page + 1,
+		HasNext:  hasNext,
+		Error:    r.URL.Query().Get("error"),
+	})
+}
+
+func (ws *webServer) handleMarkRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	err = ws.st.db.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    user.ID,
+		PostID:    postID,
+	})
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("failed to mark post read: %w", err))
+		return
+	}
+	redirectBack(w, r)
+}
+
+func (ws *webServer) handleStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	starred, _ := strconv.ParseBool(r.FormValue("starred"))
+	err = ws.st.db.SetPostStarred(r.Context(), database.SetPostStarredParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    user.ID,
+		PostID:    postID,
+		Starred:   starred,
+	})
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("failed to star post: %w", err))
+		return
+	}
+	redirectBack(w, r)
+}
+
+func (ws *webServer) handleAddFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	name := r.FormValue("name")
+	feedURL := r.FormValue("url")
+	if name == "" || feedURL == "" {
+		redirectWithError(w, r, fmt.Errorf("name and url are required"))
+		return
+	}
+
+	feed, err := ws.st.db.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      name,
+		Url:       feedURL,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("can't create feed: %w", err))
+		return
+	}
+
+	_, err = ws.st.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("could not follow feed: %w", err))
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (ws *webServer) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, err := ws.st.db.GetFeedByUrl(r.Context(), r.FormValue("url"))
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("feed not found: %w", err))
+		return
+	}
+
+	_, err = ws.st.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		redirectWithError(w, r, fmt.Errorf("could not follow feed: %w", err))
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// redirectBack returns to the page the form was posted from so that
+// pagination survives marking posts read or starred.
+func redirectBack(w http.ResponseWriter, r *http.Request) {
+	target := "/"
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Host == r.Host && ref.Path == "/" {
+		target = ref.RequestURI()
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func redirectWithError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("web: %v", err)
+	http.Redirect(w, r, "/?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+// startSession logs user in on this machine: a new random token goes to
+// the profile in the config and its hash to the database, replacing the
+// previous session.
+func startSession(s *state, user database.User) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate login token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	err := s.db.SetUserLoginTokenHash(context.Background(), database.SetUserLoginTokenHashParams{
+		LoginTokenHash: hashAPIKey(token),
+		ID:             user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store login token: %w", err)
+	}
+	return s.cfg.SetUser(user.Name, token)
+}
+
+// endSession forgets the user's login token, so a copy of the config no
+// longer works either.
+func endSession(s *state, user database.User) error {
+	return s.db.SetUserLoginTokenHash(context.Background(), database.SetUserLoginTokenHashParams{
+		LoginTokenHash: sql.NullString{},
+		ID:             user.ID,
+	})
+}
+
+// needsSession reports whether the user name in the config is not enough
+// on its own. Anyone can write a name into the config, so accounts with a
+// password and admins must show the token they got from login.
+func needsSession(user database.User) bool {
+	return user.PasswordHash.Valid || user.IsAdmin
+}
+
+func validSession(user database.User, token string) bool {
+	if token == "" || !user.LoginTokenHash.Valid {
+		return false
+	}
+	hash := hashAPIKey(token)
+	return subtle.ConstantTimeCompare([]byte(hash.String), []byte(user.LoginTokenHash.String)) == 1
+}
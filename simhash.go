@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// Posts whose fingerprints differ in at most this many of 64 bits are
+	// treated as the same story. Copies of one wire story with another
+	// headline, a dropped dateline or an added footer mostly land 3 to 12
+	// bits apart, different stories on the same subject 14 or more (see
+	// simhash_test.go). A teaser cut to half the text can miss.
+	simhashMaxDistance = 12
+	// Very short texts produce fingerprints that collide too easily.
+	simhashMinTokens = 6
+	// Only posts stored this recently are compared with new ones.
+	duplicateWindow = 72 * time.Hour
+)
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// simhash fingerprints the title and description of a post. ok is false
+// when there is too little text to fingerprint reliably.
+func simhash(title, description string) (hash uint64, ok bool) {
+	text := htmlTagRe.ReplaceAllString(title+" "+description, " ")
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	// Short and common words make unrelated texts look alike; repeated
+	// words would outweigh the rest.
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if len([]rune(token)) >= 3 && !stopWords[token] {
+			seen[stem(token)] = true
+		}
+	}
+	if len(seen) < simhashMinTokens {
+		return 0, false
+	}
+
+	var weights [64]int
+	for token := range seen {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << i
+		}
+	}
+	return hash, true
+}
+
+// stopWords are frequent English words of three letters or more. Other
+// languages keep all their words, which only makes matching stricter.
+var stopWords = map[string]bool{
+	"about": true, "after": true, "all": true, "also": true, "and": true,
+	"are": true, "been": true, "being": true, "but": true, "can": true,
+	"could": true, "for": true, "from": true, "had": true, "has": true,
+	"have": true, "her": true, "his": true, "its": true, "into": true,
+	"more": true, "not": true, "one": true, "our": true, "out": true,
+	"over": true, "said": true, "says": true, "than": true, "that": true,
+	"the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "two": true, "was": true,
+	"were": true, "what": true, "when": true, "which": true,
+	"while": true, "who": true, "will": true, "with": true, "would": true,
+	"you": true, "your": true,
+}
+
+// stem strips the commonest English suffixes, so that "cuts" and "cut" or
+// "signaled" and "signal" count as the same word in differently worded
+// copies of a story.
+func stem(token string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(token) > len(suffix)+3 && strings.HasSuffix(token, suffix) {
+			return strings.TrimSuffix(token, suffix)
+		}
+	}
+	return token
+}
+
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// duplicateIndex holds the fingerprints of recent posts for one scrape so
+// that each new post is compared without another query.
+type duplicateIndex struct {
+	posts []database.GetRecentPostFingerprintsRow
+}
+
+func loadDuplicateIndex(ctx context.Context, s *state) (*duplicateIndex, error) {
+	posts, err := s.db.GetRecentPostFingerprints(ctx, time.Now().Add(-duplicateWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fingerprints: %w", err)
+	}
+	return &duplicateIndex{posts: posts}, nil
+}
+
+// cluster finds the closest recent post from another feed and returns the
+// cluster the new post belongs to, creating one around that post if it had
+// none yet. It returns an invalid NullUUID when there is no near-duplicate.
+func (idx *duplicateIndex) cluster(ctx context.Context, s *state, feedID uuid.UUID, hash uint64) (uuid.NullUUID, error) {
+	best, bestDistance := -1, simhashMaxDistance+1
+	for i, p := range idx.posts {
+		if p.FeedID == feedID {
+			continue
+		}
+		d := hammingDistance(hash, uint64(p.Simhash.Int64))
+		if d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	if best < 0 {
+		return uuid.NullUUID{}, nil
+	}
+
+	match := &idx.posts[best]
+	if !match.ClusterID.Valid {
+		clusterID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		err := s.db.SetPostCluster(ctx, database.SetPostClusterParams{
+			ClusterID: clusterID,
+			ID:        match.ID,
+		})
+		if err != nil {
+			return uuid.NullUUID{}, fmt.Errorf("failed to cluster post: %w", err)
+		}
+		match.ClusterID = clusterID
+	}
+	return match.ClusterID, nil
+}
+
+// clusterPost puts a newly stored post into the cluster of its closest
+// near-duplicate, if any, and remembers it for the rest of the scrape.
+func clusterPost(ctx context.Context, s *state, idx *duplicateIndex, post *database.Post) error {
+	if !post.Simhash.Valid {
+		return nil
+	}
+	defer idx.add(*post)
+
+	clusterID, err := idx.cluster(ctx, s, post.FeedID, uint64(post.Simhash.Int64))
+	if err != nil || !clusterID.Valid {
+		return err
+	}
+	err = s.db.SetPostCluster(ctx, database.SetPostClusterParams{
+		ClusterID: clusterID,
+		ID:        post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cluster post: %w", err)
+	}
+	post.ClusterID = clusterID
+	return nil
+}
+
+func (idx *duplicateIndex) add(post database.Post) {
+	if !post.Simhash.Valid {
+		return
+	}
+	idx.posts = append(idx.posts, database.GetRecentPostFingerprintsRow{
+		ID:        post.ID,
+		FeedID:    post.FeedID,
+		Simhash:   post.Simhash,
+		ClusterID: post.ClusterID,
+	})
+}
+
+func nullSimhash(hash uint64, ok bool) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(hash), Valid: ok}
+}
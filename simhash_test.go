@@ -0,0 +1,141 @@
+package main
+
+import "testing"
+
+// Copies of the same wire stories as two outlets published them, and
+// different stories on the same subject from the same day.
+const (
+	fedTitle = "Fed holds interest rates steady, signals two cuts later this year"
+	fedText  = "WASHINGTON (AP) — The Federal Reserve left its benchmark interest rate unchanged Wednesday but signaled that it still expects to cut rates twice before the end of the year as inflation continues to ease."
+
+	quakeTitle = "Magnitude 7.1 earthquake strikes off Japan's coast, tsunami warning issued"
+	quakeText  = "TOKYO (AP) — A powerful magnitude 7.1 earthquake struck off the coast of southern Japan on Thursday, prompting authorities to issue a tsunami warning for coastal areas of Miyazaki and Kochi prefectures."
+
+	fireTitle = "Wildfire forces thousands to evacuate near Los Angeles"
+	fireText  = "LOS ANGELES (Reuters) - A fast-moving wildfire fueled by strong Santa Ana winds forced thousands of residents to flee their homes north of Los Angeles on Wednesday, officials said, as firefighters battled the blaze from the air."
+
+	oilTitle = "Oil prices jump after OPEC+ agrees to deeper output cuts"
+	oilText  = "LONDON (Reuters) - Oil prices rose more than 2% on Monday after OPEC+ agreed over the weekend to deepen production cuts into next year, tightening supply as demand concerns weigh on the market."
+
+	layoffTitle = "Microsoft to cut 10,000 jobs as tech layoffs widen"
+	layoffText  = "SEATTLE (AP) — Microsoft said Wednesday it will lay off 10,000 workers, or nearly 5% of its workforce, becoming the latest tech giant to cut jobs amid slowing demand and economic uncertainty."
+)
+
+func TestSimhashDistance(t *testing.T) {
+	type text struct{ title, description string }
+	tests := []struct {
+		name      string
+		a, b      text
+		duplicate bool
+	}{
+		{
+			name:      "other headline, no dateline",
+			a:         text{fedTitle, fedText},
+			b:         text{"Federal Reserve keeps rates unchanged, still sees two cuts in 2024", "The Federal Reserve left its benchmark interest rate unchanged Wednesday but signaled that it still expects to cut rates twice before the end of the year as inflation continues to ease."},
+			duplicate: true,
+		},
+		{
+			name:      "other headline, reworded",
+			a:         text{fireTitle, fireText},
+			b:         text{"Thousands flee fast-moving wildfire north of Los Angeles", "A fast-moving wildfire driven by strong Santa Ana winds forced thousands of residents north of Los Angeles to flee their homes on Wednesday, as firefighters attacked the blaze from the air, officials said."},
+			duplicate: true,
+		},
+		{
+			name:      "footer added by the republishing site",
+			a:         text{quakeTitle, quakeText},
+			b:         text{quakeTitle, "A powerful magnitude 7.1 earthquake struck off the coast of southern Japan on Thursday, prompting authorities to issue a tsunami warning for coastal areas of Miyazaki and Kochi prefectures. The post " + quakeTitle + " appeared first on Daily Herald."},
+			duplicate: true,
+		},
+		{
+			name:      "teaser cut short",
+			a:         text{fedTitle, fedText},
+			b:         text{fedTitle, fedText[:140] + "…"},
+			duplicate: true,
+		},
+		{
+			name:      "description with markup",
+			a:         text{layoffTitle, layoffText},
+			b:         text{layoffTitle, "<p><strong>SEATTLE (AP)</strong> — Microsoft said Wednesday it will lay off 10,000 workers, or nearly 5% of its <a href=\"https://example.com\">workforce</a>, becoming the latest tech giant to cut jobs amid slowing demand and economic uncertainty.</p>"},
+			duplicate: true,
+		},
+		{
+			name: "another rate decision",
+			a:    text{fedTitle, fedText},
+			b:    text{"Fed raises interest rates by a quarter point to fight inflation", "WASHINGTON (AP) — The Federal Reserve raised its benchmark interest rate by a quarter point Wednesday, its tenth increase in just over a year, as it continues its fight against high inflation."},
+		},
+		{
+			name: "another central bank",
+			a:    text{fedTitle, fedText},
+			b:    text{"Bank of England holds rates at 5.25% as inflation stays high", "LONDON (AP) — The Bank of England kept its main interest rate unchanged at 5.25% on Thursday but signaled that it could start cutting rates later this year as inflation continues to ease."},
+		},
+		{
+			name: "another earthquake",
+			a:    text{quakeTitle, quakeText},
+			b:    text{"Earthquake of magnitude 6.2 shakes central Italy, no damage reported", "ROME (AP) — An earthquake with a magnitude of 6.2 shook central Italy early Thursday, rattling residents awake, but there were no immediate reports of damage or injuries, officials said."},
+		},
+		{
+			name: "another wildfire",
+			a:    text{fireTitle, fireText},
+			b:    text{"Wildfire near Athens forces evacuations as Greece swelters", "ATHENS, Greece (AP) — A wildfire fanned by strong winds forced the evacuation of several villages north of Athens on Sunday as firefighters and water-dropping aircraft battled the blaze amid a heat wave."},
+		},
+		{
+			name: "other layoffs",
+			a:    text{layoffTitle, layoffText},
+			b:    text{"Google parent Alphabet to cut 12,000 jobs", "MOUNTAIN VIEW, Calif. (AP) — Google's parent company Alphabet said Friday it will cut 12,000 jobs, or about 6% of its workforce, the latest tech giant to lay off workers amid a slowing economy."},
+		},
+		{
+			name: "unrelated",
+			a:    text{oilTitle, oilText},
+			b:    text{quakeTitle, quakeText},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, okA := simhash(tt.a.title, tt.a.description)
+			b, okB := simhash(tt.b.title, tt.b.description)
+			if !okA || !okB {
+				t.Fatalf("simhash() ok = %v, %v, want true", okA, okB)
+			}
+			d := hammingDistance(a, b)
+			if got := d <= simhashMaxDistance; got != tt.duplicate {
+				t.Errorf("distance = %d, duplicate = %v, want %v", d, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestSimhashShortText(t *testing.T) {
+	tests := []struct {
+		title, description string
+		want               bool
+	}{
+		{"Hello world", "", false},
+		{"The fed and the rates", "It was said that they will", false},
+		{fedTitle, "", true},
+	}
+	for _, tt := range tests {
+		_, ok := simhash(tt.title, tt.description)
+		if ok != tt.want {
+			t.Errorf("simhash(%q, %q) ok = %v, want %v", tt.title, tt.description, ok, tt.want)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"cuts":     "cuts",
+		"signaled": "signal",
+		"rates":    "rate",
+		"workers":  "worker",
+		"warning":  "warn",
+		"ease":     "ease",
+		"its":      "its",
+		"news":     "news",
+		"питание":  "питание",
+	}
+	for token, want := range tests {
+		if got := stem(token); got != want {
+			t.Errorf("stem(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+-- name: UpsertFeedCredential :exec
+INSERT INTO feed_credentials (feed_id, kind, secret, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $4)
+ON CONFLICT (feed_id) DO UPDATE
+SET kind = EXCLUDED.kind, secret = EXCLUDED.secret, updated_at = EXCLUDED.updated_at;
+
+-- name: GetFeedCredential :one
+SELECT * FROM feed_credentials
+WHERE feed_id = $1;
+
+-- name: DeleteFeedCredential :execrows
+DELETE FROM feed_credentials
+WHERE feed_id = $1;
+
+-- name: GetAllFeedCredentials :many
+SELECT * FROM feed_credentials;
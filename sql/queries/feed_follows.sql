@@ -1,12 +1,14 @@
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id) VALUES
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, title, notes) VALUES
     (
         $1,
         $2,
         $3,
         $4,
-        $5
+        $5,
+        $6,
+        $7
     )
     RETURNING *
 )
@@ -23,12 +25,57 @@ INNER JOIN feeds ON inserted_feed_follow.feed_id=feeds.id;
 SELECT
   ff.*,
   u.name AS user_name,
-  f.name AS feed_name
+  f.name AS feed_name,
+  f.url AS feed_url,
+  f.last_fetched_at,
+  f.last_succeeded_at,
+  f.last_error,
+  f.consecutive_failures,
+  f.next_fetch_at,
+  COUNT(p.id) AS total_posts,
+  COUNT(p.id) FILTER (WHERE ps.read_at IS NULL) AS unread_posts,
+  COUNT(p.id) FILTER (WHERE p.published_at > NOW() - INTERVAL '30 days') AS recent_posts
 FROM feed_follows ff
 JOIN users u ON ff.user_id = u.id
 JOIN feeds f ON ff.feed_id = f.id
-WHERE ff.user_id = $1;
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+GROUP BY ff.id, u.id, f.id
+ORDER BY ff.created_at, ff.id;
+
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowTitle :exec
+UPDATE feed_follows
+SET title = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedFollowNotes :exec
+UPDATE feed_follows
+SET notes = $1, updated_at = $2
+WHERE id = $3;
 
 -- name: UnfollowUser :exec
 DELETE FROM feed_follows
-WHERE user_id =$1 AND feed_id =$2;
\ No newline at end of file
+WHERE user_id =$1 AND feed_id =$2;
+
+-- name: ListFeedFollowsForUser :many
+SELECT
+  ff.*,
+  f.name AS feed_name,
+  f.url AS feed_url
+FROM feed_follows ff
+JOIN feeds f ON ff.feed_id = f.id
+WHERE ff.user_id = $1
+ORDER BY ff.created_at, ff.id
+LIMIT $2 OFFSET $3;
+
+
+-- name: GetAllFeedFollows :many
+SELECT * FROM feed_follows;
+
+-- name: DeleteFeedFollows :execrows
+DELETE FROM feed_follows;
@@ -0,0 +1,17 @@
+-- name: UpsertFeedHeader :exec
+INSERT INTO feed_headers (feed_id, name, value, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $4)
+ON CONFLICT (feed_id, name) DO UPDATE
+SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteFeedHeader :execrows
+DELETE FROM feed_headers
+WHERE feed_id = $1 AND name = $2;
+
+-- name: GetFeedHeaders :many
+SELECT * FROM feed_headers
+WHERE feed_id = $1
+ORDER BY name;
+
+-- name: GetAllFeedHeaders :many
+SELECT * FROM feed_headers;
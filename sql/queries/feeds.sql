@@ -22,8 +22,74 @@ UPDATE feeds
 SET last_fetched_at = $1, updated_at = $1
 WHERE id = $2;
 
+-- name: MarkFeedFetchSucceeded :exec
+UPDATE feeds
+SET last_succeeded_at = $1, last_error = NULL, consecutive_failures = 0
+WHERE id = $2;
+
+-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET last_error = $1, consecutive_failures = consecutive_failures + 1
+WHERE id = $2;
+
 -- name: GetNextFeedToFetch :one
 SELECT *
 FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::timestamp
 ORDER BY last_fetched_at NULLS FIRST, updated_at ASC
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: ListFeeds :many
+SELECT * FROM feeds
+ORDER BY created_at, id
+LIMIT $1 OFFSET $2;
+
+
+-- name: GetAllFeeds :many
+SELECT * FROM feeds;
+
+-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedAutoDownload :exec
+UPDATE feeds
+SET auto_download = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = $2;
+
+-- name: SetFeedOwner :exec
+UPDATE feeds
+SET user_id = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: TransferFeedsOwnedBy :execrows
+UPDATE feeds
+SET user_id = sqlc.arg(new_owner_id), updated_at = sqlc.arg(updated_at)
+WHERE user_id = sqlc.arg(old_owner_id);
+
+-- name: GetFeedDeletionCounts :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = $1) AS posts;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: DeleteFeeds :exec
+DELETE FROM feeds;
@@ -0,0 +1,25 @@
+-- name: CreateFilter :one
+INSERT INTO filters (id, created_at, updated_at, user_id, feed_id, field, match_type, pattern, action)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: ListFiltersForUser :many
+SELECT filters.*, feeds.url AS feed_url
+FROM filters
+LEFT JOIN feeds ON feeds.id = filters.feed_id
+WHERE filters.user_id = $1
+ORDER BY filters.created_at, filters.id;
+
+-- name: GetFiltersForFeedFollowers :many
+SELECT filters.*
+FROM filters
+JOIN feed_follows ON feed_follows.user_id = filters.user_id AND feed_follows.feed_id = $1
+WHERE filters.feed_id IS NULL OR filters.feed_id = $1
+ORDER BY filters.user_id, filters.created_at;
+
+-- name: DeleteFilter :execrows
+DELETE FROM filters
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetAllFilters :many
+SELECT * FROM filters;
@@ -1,12 +1,56 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, simhash, description_html, description_text, content_html, content_text, author, categories, comments_url, episode_duration, episode_number, episode_image)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
 RETURNING *;
 
+-- name: GetRecentPostFingerprints :many
+SELECT id, feed_id, simhash, cluster_id
+FROM posts
+WHERE simhash IS NOT NULL AND created_at > $1;
+
+-- name: SetPostContent :exec
+UPDATE posts
+SET content_html = $1, content_text = $2, updated_at = $3
+WHERE id = $4;
+
+-- name: SetPostCluster :exec
+UPDATE posts
+SET cluster_id = $1
+WHERE id = $2;
+
 -- name: GetPostsForUser :many
-SELECT posts.*
+-- Every copy of a duplicated story is returned: listPosts applies the
+-- user's filters and then picks the copy that represents the cluster.
+SELECT
+    posts.*,
+    COALESCE(feed_follows.title, feeds.name) AS feed_title,
+    COALESCE(post_states.starred, FALSE) AS starred
 FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+    SELECT 1 FROM feed_follow_tags
+    JOIN tags ON feed_follow_tags.tag_id = tags.id
+    WHERE feed_follow_tags.feed_follow_id = feed_follows.id AND tags.name = sqlc.narg('tag')
+))
+AND (sqlc.narg('author')::text IS NULL OR lower(posts.author) = lower(sqlc.narg('author')))
+AND (sqlc.narg('category')::text IS NULL OR EXISTS (
+    SELECT 1 FROM unnest(posts.categories) AS category
+    WHERE lower(category) = lower(sqlc.narg('category'))
+))
+AND (sqlc.narg('search')::text IS NULL
+    OR posts.title ILIKE '%' || sqlc.narg('search') || '%'
+    OR COALESCE(posts.description_text, posts.description) ILIKE '%' || sqlc.narg('search') || '%'
+    OR posts.content_text ILIKE '%' || sqlc.narg('search') || '%')
+AND (NOT sqlc.arg('unread_only')::boolean OR post_states.read_at IS NULL)
+AND (sqlc.narg('cluster_ids')::uuid[] IS NULL OR posts.cluster_id = ANY(sqlc.narg('cluster_ids')::uuid[]))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetAllPosts :many
+SELECT * FROM posts;
+
+-- name: DeletePosts :execrows
+DELETE FROM posts;
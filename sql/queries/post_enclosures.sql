@@ -0,0 +1,41 @@
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (id, created_at, post_id, url, mime_type, length)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetPostEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = $1
+ORDER BY created_at, url;
+
+-- name: GetAllPostEnclosures :many
+SELECT * FROM post_enclosures;
+
+-- name: GetEpisodesForUser :many
+SELECT
+    posts.id AS post_id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    posts.episode_duration,
+    posts.episode_number,
+    posts.episode_image,
+    COALESCE(feed_follows.title, feeds.name) AS feed_title,
+    feeds.name AS feed_name,
+    post_enclosures.url AS enclosure_url,
+    post_enclosures.mime_type,
+    post_enclosures.length
+FROM post_enclosures
+JOIN posts ON posts.id = post_enclosures.post_id
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND (post_enclosures.mime_type LIKE 'audio/%' OR post_enclosures.mime_type LIKE 'video/%')
+AND (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url'))
+AND (
+    (sqlc.narg('post_id_prefix')::text IS NULL AND sqlc.narg('post_url')::text IS NULL)
+    OR posts.id::text LIKE sqlc.narg('post_id_prefix') || '%'
+    OR posts.url = sqlc.narg('post_url')
+)
+ORDER BY posts.published_at DESC, post_enclosures.url
+LIMIT $5;
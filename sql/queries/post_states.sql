@@ -0,0 +1,19 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at)
+VALUES ($1, $2, $2, $3, $4, $2)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at;
+
+-- name: MarkPostUnread :exec
+UPDATE post_states
+SET read_at = NULL, updated_at = $1
+WHERE user_id = $2 AND post_id = $3;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, starred)
+VALUES ($1, $2, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id)
+DO UPDATE SET starred = EXCLUDED.starred, updated_at = EXCLUDED.updated_at;
+
+-- name: GetAllPostStates :many
+SELECT * FROM post_states;
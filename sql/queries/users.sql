@@ -1,10 +1,13 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, api_key_hash, password_hash, is_admin)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
@@ -19,4 +22,72 @@ DELETE FROM users;
 SELECT * FROM users;
 
 -- name: GetUserById :one
-SELECT * FROM users WHERE id = $1;
\ No newline at end of file
+SELECT * FROM users WHERE id = $1;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at, id
+LIMIT $1 OFFSET $2;
+
+-- name: GetUserByAPIKeyHash :one
+SELECT * FROM users
+WHERE api_key_hash = $1;
+
+-- name: SetUserAPIKeyHash :exec
+UPDATE users
+SET api_key_hash = $1, updated_at = $2
+WHERE id = $3;
+
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
+
+-- name: SetUserPasswordHash :exec
+UPDATE users
+SET password_hash = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetUserAdmin :exec
+UPDATE users
+SET is_admin = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: SetUserLoginTokenHash :exec
+UPDATE users
+SET login_token_hash = $1
+WHERE id = $2;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: GetTableCounts :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM feeds) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows) AS feed_follows,
+    (SELECT COUNT(*) FROM posts) AS posts,
+    (SELECT COUNT(*) FROM post_states) AS post_states;
+
+-- name: GetUserDeletionCounts :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS feed_follows;
+
+-- name: RenameUser :exec
+UPDATE users
+SET name = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: GetOtherAdmin :one
+SELECT * FROM users
+WHERE is_admin AND id <> $1
+ORDER BY created_at
+LIMIT 1;
+
+-- name: GetUsersWithStats :many
+SELECT
+    users.*,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = users.id) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = users.id AND post_states.read_at IS NOT NULL) AS posts_read
+FROM users
+ORDER BY users.created_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN password_hash TEXT,
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The oldest account becomes the first admin so existing installs keep
+-- someone who can run reset.
+UPDATE users SET is_admin = TRUE
+WHERE id = (SELECT id FROM users ORDER BY created_at LIMIT 1);
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN password_hash,
+DROP COLUMN is_admin;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Feeds are shared by every follower, so deleting the user who added one
+-- must not take it away from everyone else. The application hands such
+-- feeds over to an admin before deleting the user.
+ALTER TABLE feeds
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- simhash is a 64-bit fingerprint of the normalized title and description;
+-- posts from different feeds with nearly equal fingerprints share a
+-- cluster_id. Unclustered posts have a NULL cluster_id.
+ALTER TABLE posts
+ADD COLUMN simhash BIGINT,
+ADD COLUMN cluster_id UUID;
+
+CREATE INDEX posts_cluster_id_idx ON posts (cluster_id);
+CREATE INDEX posts_created_at_idx ON posts (created_at);
+
+-- +goose Down
+DROP INDEX posts_created_at_idx;
+DROP INDEX posts_cluster_id_idx;
+
+ALTER TABLE posts
+DROP COLUMN cluster_id,
+DROP COLUMN simhash;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN fetch_full_content BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE posts
+ADD COLUMN content_html TEXT,
+ADD COLUMN content_text TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content_text,
+DROP COLUMN content_html;
+
+ALTER TABLE feeds
+DROP COLUMN fetch_full_content;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- description keeps the markup as received; description_html is the
+-- sanitized variant and description_text the plain-text one.
+ALTER TABLE posts
+ADD COLUMN description_html TEXT,
+ADD COLUMN description_text TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN description_text,
+DROP COLUMN description_html;
@@ -0,0 +1,39 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN author TEXT,
+ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN comments_url TEXT;
+
+CREATE INDEX posts_author_idx ON posts (lower(author));
+CREATE INDEX posts_categories_idx ON posts USING GIN (categories);
+
+CREATE TABLE post_enclosures (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    mime_type TEXT NOT NULL DEFAULT '',
+    length BIGINT,
+    UNIQUE (post_id, url)
+);
+
+ALTER TABLE filters
+DROP CONSTRAINT filters_field_check,
+ADD CONSTRAINT filters_field_check CHECK (field IN ('any', 'title', 'description', 'url', 'author', 'category'));
+
+-- +goose Down
+DELETE FROM filters WHERE field IN ('author', 'category');
+
+ALTER TABLE filters
+DROP CONSTRAINT filters_field_check,
+ADD CONSTRAINT filters_field_check CHECK (field IN ('any', 'title', 'description', 'url'));
+
+DROP TABLE post_enclosures;
+
+DROP INDEX posts_categories_idx;
+DROP INDEX posts_author_idx;
+
+ALTER TABLE posts
+DROP COLUMN comments_url,
+DROP COLUMN categories,
+DROP COLUMN author;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN episode_duration INTEGER,
+ADD COLUMN episode_number INTEGER,
+ADD COLUMN episode_image TEXT;
+
+ALTER TABLE feeds
+ADD COLUMN auto_download BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN auto_download;
+
+ALTER TABLE posts
+DROP COLUMN episode_image,
+DROP COLUMN episode_number,
+DROP COLUMN episode_duration;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE feed_headers (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (feed_id, name)
+);
+
+-- +goose Down
+DROP TABLE feed_headers;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN login_token_hash TEXT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN login_token_hash;
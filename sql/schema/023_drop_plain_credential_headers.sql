@@ -0,0 +1,9 @@
+-- +goose Up
+-- Credentials are stored encrypted in feed_credentials. Copies saved in
+-- plain text with feed set-header before that are dropped; set them again
+-- with feed auth.
+DELETE FROM feed_headers
+WHERE lower(name) IN ('authorization', 'proxy-authorization', 'cookie');
+
+-- +goose Down
+-- The deleted headers can't be restored.
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const tagUsage = `usage:
+  tag add <url> <tag>...
+  tag remove <url> <tag>...
+  tag list`
+
+func handlerTag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf(tagUsage)
+	}
+	ctx := context.Background()
+	switch cmd.args[0] {
+	case "add":
+		if len(cmd.args) < 3 {
+			return fmt.Errorf("usage: tag add <url> <tag>...")
+		}
+		follow, feed, err := getFollowByURL(ctx, s, user, cmd.args[1])
+		if err != nil {
+			return err
+		}
+		for _, name := range cmd.args[2:] {
+			err := addTag(ctx, s.db, user, follow, name)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Tagged %q with %s\n", feed.Name, strings.Join(cmd.args[2:], ", "))
+		return nil
+	case "remove":
+		if len(cmd.args) < 3 {
+			return fmt.Errorf("usage: tag remove <url> <tag>...")
+		}
+		follow, feed, err := getFollowByURL(ctx, s, user, cmd.args[1])
+		if err != nil {
+			return err
+		}
+		for _, name := range cmd.args[2:] {
+			err := removeTag(ctx, s, user, follow, name)
+			if err != nil {
+				return err
+			}
+		}
+		// Tags only exist through the follows they are attached to.
+		err = s.db.DeleteUnusedTags(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to clean up tags: %w", err)
+		}
+		fmt.Printf("Removed %s from %q\n", strings.Join(cmd.args[2:], ", "), feed.Name)
+		return nil
+	case "list":
+		tags, err := s.db.ListTagsForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get tags: %w", err)
+		}
+		if len(tags) == 0 {
+			fmt.Println("You have no tags.")
+			return nil
+		}
+		for _, t := range tags {
+			fmt.Printf("%s (%d feeds)\n", t.Name, t.Follows)
+		}
+		return nil
+	default:
+		return fmt.Errorf(tagUsage)
+	}
+}
+
+func getFollowByURL(ctx context.Context, s *state, user database.User, url string) (database.FeedFollow, database.Feed, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.FeedFollow{}, feed, fmt.Errorf("feed not found")
+	}
+	if err != nil {
+		return database.FeedFollow{}, feed, fmt.Errorf("can't find the feed %w", err)
+	}
+	follow, err := s.db.GetFeedFollow(ctx, database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return follow, feed, fmt.Errorf("you don't follow %s", url)
+	}
+	if err != nil {
+		return follow, feed, fmt.Errorf("failed to get follow: %w", err)
+	}
+	return follow, feed, nil
+}
+
+// normalizeTag rejects names that can't survive an OPML round trip, where
+// a follow's tags are written as one comma-separated category attribute.
+func normalizeTag(name string) (string, error) {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	if name == "" {
+		return "", fmt.Errorf("tag name can't be empty")
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("tag name can't contain a comma: %q", name)
+	}
+	return name, nil
+}
+
+func addTag(ctx context.Context, q *database.Queries, user database.User, follow database.FeedFollow, name string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	tag, err := q.UpsertTag(ctx, database.UpsertTagParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("can't create tag: %w", err)
+	}
+	err = q.AddFeedFollowTag(ctx, database.AddFeedFollowTagParams{
+		FeedFollowID: follow.ID,
+		TagID:        tag.ID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("can't tag feed: %w", err)
+	}
+	return nil
+}
+
+func removeTag(ctx context.Context, s *state, user database.User, follow database.FeedFollow, name string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	tag, err := s.db.GetTag(ctx, database.GetTagParams{UserID: user.ID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("tag %q does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get tag: %w", err)
+	}
+	n, err := s.db.RemoveFeedFollowTag(ctx, database.RemoveFeedFollowTagParams{
+		FeedFollowID: follow.ID,
+		TagID:        tag.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't untag feed: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("feed is not tagged %q", name)
+	}
+	return nil
+}
+
+// tagsByFollow maps each of the user's follows to its tag names, sorted.
+func tagsByFollow(ctx context.Context, s *state, user database.User) (map[uuid.UUID][]string, error) {
+	rows, err := s.db.GetFeedFollowTagsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	tags := make(map[uuid.UUID][]string)
+	for _, r := range rows {
+		tags[r.FeedFollowID] = append(tags[r.FeedFollowID], r.Name)
+	}
+	return tags, nil
+}
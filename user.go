@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Maxeminator/blog-aggregator/internal/database"
+)
+
+const userUsage = `usage:
+  user rename [<username>] <new-name>
+  user delete [<username>] [--yes]`
+
+func handlerUser(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf(userUsage)
+	}
+	switch cmd.args[0] {
+	case "rename":
+		return userRename(s, user, cmd.args[1:])
+	case "delete":
+		return userDelete(s, user, cmd.args[1:], cmd.boolFlag("yes"))
+	default:
+		return fmt.Errorf(userUsage)
+	}
+}
+
+// resolveTargetUser returns the named user when an admin names one and the
+// current user otherwise; only admins may act on other accounts.
+func resolveTargetUser(s *state, current database.User, name string) (database.User, error) {
+	if name == "" || name == current.Name {
+		return current, nil
+	}
+	if !current.IsAdmin {
+		return database.User{}, fmt.Errorf("only admins can manage other users")
+	}
+	target, err := s.db.GetUser(context.Background(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("user does not exist")
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return target, nil
+}
+
+func userRename(s *state, current database.User, args []string) error {
+	var name, newName string
+	switch len(args) {
+	case 1:
+		newName = args[0]
+	case 2:
+		name, newName = args[0], args[1]
+	default:
+		return fmt.Errorf("usage: user rename [<username>] <new-name>")
+	}
+
+	target, err := resolveTargetUser(s, current, name)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.GetUser(context.Background(), newName)
+	if err == nil {
+		return fmt.Errorf("user already exists")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+
+	err = s.db.RenameUser(context.Background(), database.RenameUserParams{
+		Name:      newName,
+		UpdatedAt: time.Now(),
+		ID:        target.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename user: %w", err)
+	}
+
+	if s.cfg.CurrentUserName == target.Name {
+		err = s.cfg.SetUser(newName, s.cfg.LoginToken)
+		if err != nil {
+			return fmt.Errorf("failed to set current user: %w", err)
+		}
+	}
+	fmt.Printf("user %s renamed to %s\n", target.Name, newName)
+	return nil
+}
+
+func userDelete(s *state, current database.User, args []string, yes bool) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	target, err := resolveTargetUser(s, current, name)
+	if err != nil {
+		return err
+	}
+
+	counts, err := s.db.GetUserDeletionCounts(context.Background(), target.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	// Feeds are shared, so whoever deletes the account needs an admin to
+	// hand them to: the acting admin, or the oldest other admin when users
+	// delete themselves.
+	heir := current
+	if target.ID == current.ID {
+		heir, err = s.db.GetOtherAdmin(context.Background(), target.ID)
+		if errors.Is(err, sql.ErrNoRows) && target.IsAdmin {
+			return fmt.Errorf("%s is the last admin; grant admin rights to someone else first", target.Name)
+		}
+		if errors.Is(err, sql.ErrNoRows) && counts.Feeds > 0 {
+			return fmt.Errorf("%s added %d feeds and there is no other admin to take them over", target.Name, counts.Feeds)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to find an admin: %w", err)
+		}
+	}
+
+	fmt.Printf("User %s will be deleted together with %d feed follows.\n", target.Name, counts.FeedFollows)
+	if counts.Feeds > 0 {
+		fmt.Printf("%d feeds added by %s are kept and handed over to %s.\n", counts.Feeds, target.Name, heir.Name)
+	}
+	if !yes {
+		ok, err := confirm(`Type "yes" to continue: `)
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !ok {
+			fmt.Println("delete cancelled")
+			return nil
+		}
+	}
+
+	_, err = deleteUserKeepingFeeds(context.Background(), s, target, heir)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if s.cfg.CurrentUserName == target.Name {
+		err = s.cfg.SetUser("", "")
+		if err != nil {
+			return fmt.Errorf("failed to clear current user: %w", err)
+		}
+	}
+	fmt.Printf("user %s deleted\n", target.Name)
+	return nil
+}
+
+func handlerLogout(s *state, cmd command) error {
+	if s.cfg.CurrentUserName == "" {
+		fmt.Println("no user logged in")
+		return nil
+	}
+	name := s.cfg.CurrentUserName
+	if user, err := s.db.GetUser(context.Background(), name); err == nil && validSession(user, s.cfg.LoginToken) {
+		err = endSession(s, user)
+		if err != nil {
+			return fmt.Errorf("can't end the session: %w", err)
+		}
+	}
+	err := s.cfg.SetUser("", "")
+	if err != nil {
+		return fmt.Errorf("can't clear the username: %w", err)
+	}
+	fmt.Printf("logged out %s\n", name)
+	if os.Getenv(apiKeyEnvVar) != "" {
+		fmt.Printf("note: %s is still set and keeps authenticating commands\n", apiKeyEnvVar)
+	}
+	return nil
+}
+
+func handlerWhoami(s *state, cmd command, user database.User) error {
+	source := "config file"
+	if validSession(user, s.cfg.LoginToken) {
+		source = "login token in the config file"
+	}
+	if os.Getenv(apiKeyEnvVar) != "" {
+		source = apiKeyEnvVar
+	}
+	role := "user"
+	if user.IsAdmin {
+		role = "admin"
+	}
+	fmt.Printf("%s (%s, authenticated via %s)\n", user.Name, role, source)
+	return nil
+}